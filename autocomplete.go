@@ -0,0 +1,270 @@
+package ctoai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/cto-ai/sdk-go/v2/internal/daemon"
+)
+
+const (
+	defaultAutocompleteDebounce = 200 * time.Millisecond
+	defaultAutocompleteLimit    = 50
+	autocompletePollInterval    = 50 * time.Millisecond
+)
+
+// AutocompleteSource returns the choices matching query, the text the user
+// has typed into an Autocomplete prompt. ctx is done once the prompt is
+// answered.
+type AutocompleteSource func(ctx context.Context, query string) ([]Choice, error)
+
+// autocompletePrompt is an autocomplete prompt definition along with the
+// settings of the SDK serving its choices
+type autocompletePrompt struct {
+	daemon.AutocompletePromptBody
+	debounce time.Duration
+	limit    int
+}
+
+// AutocompleteOption is an option for the Autocomplete prompt function
+type AutocompleteOption func(*autocompletePrompt)
+
+// OptAutocompleteFlag sets the flag value for the autocomplete prompt.
+//
+// The flag value is used to match command line arguments to prompts.
+func OptAutocompleteFlag(flag string) AutocompleteOption {
+	return func(definition *autocompletePrompt) {
+		definition.Flag = flag
+	}
+}
+
+// OptAutocompleteDebounce sets how long the query typed by the user must
+// stay unchanged before the source is asked for its matches. Defaults to
+// 200ms.
+func OptAutocompleteDebounce(debounce time.Duration) AutocompleteOption {
+	return func(definition *autocompletePrompt) {
+		definition.debounce = debounce
+	}
+}
+
+// OptAutocompleteLimit sets the most choices shown for a query; further
+// choices returned by the source are dropped. 0 means no limit. Defaults
+// to 50.
+func OptAutocompleteLimit(limit int) AutocompleteOption {
+	return func(definition *autocompletePrompt) {
+		definition.limit = limit
+	}
+}
+
+// OptAutocompleteValidate sets a function that checks the label of the
+// choice selected in the autocomplete prompt; see OptInputValidate.
+func OptAutocompleteValidate(validate func(string) error) AutocompleteOption {
+	return func(definition *autocompletePrompt) {
+		definition.Validate = func(answer interface{}) error {
+			value, _ := answer.(string)
+			return validate(value)
+		}
+	}
+}
+
+// Autocomplete presents a search box to the user in the interface (i.e.
+// terminal or slack) and returns the Value of the selected choice. As the
+// user types, source is called with the query from a separate goroutine
+// and its matches are shown to select from, so that choices can be looked
+// up from large or remote collections.
+//
+// The SDK polls the daemon for the query while the prompt is open. A query
+// is passed to source once it has stayed unchanged for the debounce
+// interval set with OptAutocompleteDebounce, and at most the number of
+// choices set with OptAutocompleteLimit are shown. An error from source is
+// shown to the user in place of the choices.
+//
+// Daemons that do not support FeatureAutocompleteDynamic are asked a
+// static autocomplete prompt of the choices matching an empty query.
+//
+// Since the polling depends on timing, a recorded cassette holding a
+// dynamic autocomplete prompt cannot be replayed; ops using it can be
+// tested with the fake daemon of package ctoaitest, whose Type method
+// queues the queries typed by the user.
+//
+// Example:
+//
+//  p := ctoai.NewPrompt()
+//  repo, err := p.Autocomplete("repo", "Which repository?", func(ctx context.Context, query string) ([]ctoai.Choice, error) {
+//      repos, err := github.SearchRepos(ctx, query)
+//      if err != nil {
+//          return nil, err
+//      }
+//      choices := make([]ctoai.Choice, len(repos))
+//      for i, r := range repos {
+//          choices[i] = ctoai.Choice{Label: r.FullName, Value: r.ID, Description: r.Description}
+//      }
+//      return choices, nil
+//  }, ctoai.OptAutocompleteLimit(20)) // user types sdk-go and selects cto-ai/sdk-go
+//  if err != nil {
+//      panic(err)
+//  }
+//
+//  fmt.Println(repo)
+//
+// Output:
+// 12345
+func (p *Prompt) Autocomplete(name, msg string, source AutocompleteSource, options ...AutocompleteOption) (interface{}, error) {
+	return p.AutocompleteContext(context.Background(), name, msg, source, options...)
+}
+
+// AutocompleteContext is like Autocomplete but uses ctx to cancel or time
+// out the daemon request.
+func (p *Prompt) AutocompleteContext(ctx context.Context, name, msg string, source AutocompleteSource, options ...AutocompleteOption) (interface{}, error) {
+	definition := &autocompletePrompt{
+		AutocompletePromptBody: daemon.AutocompletePromptBody{
+			PromptEnvelope: daemon.PromptEnvelope{
+				Name:       name,
+				PromptType: "autocomplete",
+				Message:    msg,
+			},
+			Dynamic: true,
+		},
+		debounce: defaultAutocompleteDebounce,
+		limit:    defaultAutocompleteLimit,
+	}
+	for _, option := range options {
+		option(definition)
+	}
+
+	caps, err := p.transport.Capabilities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	initial, err := definition.complete(ctx, source, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if !caps.SupportsFeature(FeatureAutocompleteDynamic) {
+		validate := definition.Validate
+		return p.ListChoicesContext(ctx, name, msg, initial, OptListFlag(definition.Flag), OptListAutocomplete(true), func(list *daemon.ListPromptBody) {
+			list.Validate = validate
+		})
+	}
+
+	s := &choiceSet{labeled: true}
+	definition.Choices = s.replace(initial)
+	s.pin()
+	definition.Serve = func(ctx context.Context) error {
+		return p.serveCompletions(ctx, definition, source, s)
+	}
+	s.guard(&definition.PromptEnvelope, daemon.SelectionConstraints{}, true)
+
+	answer, err := p.askValidated(ctx, definition.AutocompletePromptBody, name, s.decodeOne)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.values(answer.(selection))[0], nil
+}
+
+// complete returns the choices from source matching query, up to the limit
+func (definition *autocompletePrompt) complete(ctx context.Context, source AutocompleteSource, query string) ([]Choice, error) {
+	choices, err := source(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if definition.limit > 0 && len(choices) > definition.limit {
+		choices = choices[:definition.limit]
+	}
+	return choices, nil
+}
+
+// askServing asks an autocomplete prompt while serving its choices. An
+// error serving the choices closes the prompt and is returned; if the
+// daemon turns out not to serve the queries, the prompt is left open with
+// its initial choices.
+func (p *Prompt) askServing(ctx context.Context, definition daemon.AutocompletePromptBody) (map[string]interface{}, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	serving := make(chan error, 1)
+	go func() {
+		err := definition.Serve(ctx)
+		if err != nil {
+			cancel()
+		}
+		serving <- err
+	}()
+
+	body, err := p.transport.AsyncRequest(ctx, "prompt", definition, "POST")
+	cancel()
+	if serveErr := <-serving; serveErr != nil {
+		return nil, serveErr
+	}
+	return body, err
+}
+
+// serveCompletions polls the daemon for the query typed into the prompt
+// and posts the choices matching each new query, adding them to s, until
+// ctx is done or the daemon turns out not to serve the queries. The polls
+// bypass the middleware, as they are sent many times a second.
+func (p *Prompt) serveCompletions(ctx context.Context, definition *autocompletePrompt, source AutocompleteSource, s *choiceSet) error {
+	ticker := time.NewTicker(autocompletePollInterval)
+	defer ticker.Stop()
+
+	// The choices for an empty query were sent with the prompt
+	served, typed, since := "", "", time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		value, err := p.transport.PollRequest(ctx, "autocomplete/query", daemon.AutocompleteQueryBody{Name: definition.Name}, "POST")
+		if ctx.Err() != nil || unserved(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		reply, _ := value.(map[string]interface{})
+		query, err := decodeString(reply, "query")
+		if err != nil {
+			return err
+		}
+
+		if query != typed {
+			typed, since = query, time.Now()
+		}
+		if query == served || time.Since(since) < definition.debounce {
+			continue
+		}
+
+		body := daemon.AutocompleteChoicesBody{Name: definition.Name, Query: query, Choices: []daemon.LabeledChoice{}}
+		choices, err := definition.complete(ctx, source, query)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			body.Error = err.Error()
+		} else {
+			body.Choices = s.replace(choices)
+		}
+
+		err = p.transport.SimpleRequest(ctx, "autocomplete/choices", body, "POST")
+		if ctx.Err() != nil || unserved(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		served = query
+	}
+}
+
+// unserved reports whether err means that the daemon does not serve the
+// endpoint of a request
+func unserved(err error) bool {
+	var daemonErr *DaemonError
+	return errors.Is(err, ErrUnsupported) || (errors.As(err, &daemonErr) && daemonErr.StatusCode == http.StatusNotFound)
+}
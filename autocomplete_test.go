@@ -0,0 +1,142 @@
+package ctoai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func numbers(ctx context.Context, query string) ([]Choice, error) {
+	var choices []Choice
+	for i := 0; i < 100; i++ {
+		choices = append(choices, Choice{Label: fmt.Sprintf("%s%d", query, i), Value: i})
+	}
+	return choices, nil
+}
+
+func Test_Autocomplete_Static(t *testing.T) {
+	var caps interface{}
+	json.Unmarshal([]byte(`{"version": "1", "endpoints": ["prompt"], "prompts": ["list", "autocomplete"], "features": ["choices.labeled"]}`), &caps)
+	script := &scriptedPrompts{capabilities: caps, answers: []interface{}{"2"}}
+	p := NewPrompt(OptClientHandler(script.handle))
+
+	n, err := p.Autocomplete("n", "Which number?", numbers, OptAutocompleteLimit(3), OptAutocompleteFlag("n"))
+	if err != nil {
+		t.Fatalf("Error in prompt request: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("Error unexpected answer: %v", n)
+	}
+
+	definition := script.definitions[0]
+	if definition["type"] != "autocomplete" || definition["flag"] != "n" || definition["dynamic"] != nil {
+		t.Errorf("Error unexpected definition: %v", definition)
+	}
+	if choices, _ := definition["choices"].([]interface{}); len(choices) != 3 {
+		t.Errorf("Error expected 3 choices, got %v", choices)
+	}
+}
+
+func Test_Autocomplete_SourceError(t *testing.T) {
+	script := &scriptedPrompts{}
+	p := NewPrompt(OptClientHandler(script.handle))
+
+	errSearch := errors.New("search failed")
+	_, err := p.Autocomplete("n", "Which number?", func(ctx context.Context, query string) ([]Choice, error) {
+		return nil, errSearch
+	})
+	if !errors.Is(err, errSearch) {
+		t.Errorf("Error expected the source error, got: %v", err)
+	}
+	if len(script.definitions) != 0 {
+		t.Errorf("Error unexpected prompts: %v", script.definitions)
+	}
+}
+
+func Test_Autocomplete_Validate(t *testing.T) {
+	script := &scriptedPrompts{capabilities: fullCapabilities, answers: []interface{}{"0", "1"}}
+	p := NewPrompt(OptClientHandler(script.handle))
+
+	odd := OptAutocompleteValidate(func(label string) error {
+		if label == "0" {
+			return errors.New("0 is not allowed")
+		}
+		return nil
+	})
+	n, err := p.Autocomplete("n", "Which number?", numbers, odd, OptAutocompleteLimit(2))
+	if err != nil {
+		t.Fatalf("Error in prompt request: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("Error unexpected answer: %v", n)
+	}
+
+	if !reflect.DeepEqual(script.prints, []string{"0 is not allowed"}) {
+		t.Errorf("Error unexpected prints: %v", script.prints)
+	}
+	if script.definitions[0]["dynamic"] != true || len(script.definitions) != 2 {
+		t.Errorf("Error unexpected definitions: %v", script.definitions)
+	}
+}
+
+func Test_Autocomplete_QueryNotServed(t *testing.T) {
+	var mu sync.Mutex
+	var seen []string
+	polls := 0
+	polled := make(chan struct{})
+	c := NewClient(OptClientHandler(func(ctx context.Context, req *DaemonRequest) (interface{}, error) {
+		switch req.Endpoint {
+		case "capabilities":
+			return fullCapabilities, nil
+		case "autocomplete/query":
+			mu.Lock()
+			defer mu.Unlock()
+			polls++
+			if polls == 1 {
+				close(polled)
+			}
+			return nil, &DaemonError{StatusCode: http.StatusNotFound, Endpoint: req.Endpoint, Method: req.Method}
+		case "prompt":
+			select {
+			case <-polled:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			time.Sleep(3 * autocompletePollInterval)
+			return map[string]interface{}{"n": "1"}, nil
+		}
+		t.Errorf("Error unexpected request to %s", req.Endpoint)
+		return nil, nil
+	}))
+	c.Use(func(next Handler) Handler {
+		return func(ctx context.Context, req *DaemonRequest) (interface{}, error) {
+			mu.Lock()
+			seen = append(seen, req.Endpoint)
+			mu.Unlock()
+			return next(ctx, req)
+		}
+	})
+
+	n, err := c.Prompt.Autocomplete("n", "Which number?", numbers)
+	if err != nil {
+		t.Fatalf("Error in prompt request: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("Error unexpected answer: %v", n)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if polls != 1 {
+		t.Errorf("Error expected serving to stop after the first poll, got %d polls", polls)
+	}
+	if !reflect.DeepEqual(seen, []string{"capabilities", "prompt"}) {
+		t.Errorf("Error polls should bypass the middleware, saw %v", seen)
+	}
+}
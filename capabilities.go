@@ -0,0 +1,128 @@
+package ctoai
+
+import (
+	"context"
+
+	"github.com/cto-ai/sdk-go/v2/internal/daemon"
+)
+
+// Capabilities describes the protocol version of the daemon and the
+// endpoints, prompt types and optional features it supports.
+//
+// Daemons that predate capability negotiation report no version and are
+// assumed to support only what every daemon did before: the endpoints and
+// prompt types of the original protocol, password confirmation and
+// datetime variants. Forms, duration prompts, fractional numbers, labeled
+// choices and dynamic autocompletion all need a daemon that reports them.
+type Capabilities = daemon.Capabilities
+
+// Optional features that a daemon may not support
+const (
+	// FeaturePasswordConfirm is asking for a password twice, see
+	// OptPasswordConfirm
+	FeaturePasswordConfirm = daemon.FeaturePasswordConfirm
+	// FeatureDatetimeVariant is asking for only a date or only a time, see
+	// OptDatetimeVariant
+	FeatureDatetimeVariant = daemon.FeatureDatetimeVariant
+	// FeatureNumberFloat is asking for a fractional number, see
+	// Prompt.Float
+	FeatureNumberFloat = daemon.FeatureNumberFloat
+	// FeatureChoicesLabeled is showing choices with labels and
+	// descriptions, see Prompt.ListChoices
+	FeatureChoicesLabeled = daemon.FeatureChoicesLabeled
+	// FeatureAutocompleteDynamic is serving the choices of an autocomplete
+	// prompt as the user types, see Prompt.Autocomplete
+	FeatureAutocompleteDynamic = daemon.FeatureAutocompleteDynamic
+)
+
+// Capabilities asks the daemon which protocol version and features it
+// supports. The daemon is only asked once per client; later calls return
+// the same answer.
+//
+// The SDK negotiates capabilities by itself before using optional
+// features, falling back where it can, e.g. from an autocomplete prompt
+// to a list, and otherwise failing with an error matching ErrUnsupported.
+// Once negotiated, requests to endpoints the daemon does not serve fail
+// without being sent.
+func (c Client) Capabilities() (Capabilities, error) {
+	return c.CapabilitiesContext(context.Background())
+}
+
+// CapabilitiesContext is like Capabilities but uses ctx to cancel or time
+// out the daemon request.
+func (c Client) CapabilitiesContext(ctx context.Context) (Capabilities, error) {
+	return c.transport.Capabilities(ctx)
+}
+
+// ask sends a prompt definition to the daemon, adapted to its capabilities
+func (p *Prompt) ask(ctx context.Context, definition interface{}) (map[string]interface{}, error) {
+	definition, err := p.negotiate(ctx, definition)
+	if err != nil {
+		return nil, err
+	}
+	if d, ok := definition.(daemon.AutocompletePromptBody); ok && d.Serve != nil {
+		return p.askServing(ctx, d)
+	}
+	return p.transport.AsyncRequest(ctx, "prompt", definition, "POST")
+}
+
+// negotiate adapts a prompt definition to the daemon's capabilities. Only
+// prompts using features beyond the baseline cause the capabilities to be
+// fetched; baseline prompt types and features are only checked against
+// capabilities that have already been negotiated.
+func (p *Prompt) negotiate(ctx context.Context, definition interface{}) (interface{}, error) {
+	switch d := definition.(type) {
+	case daemon.PasswordPromptBody:
+		if !d.Confirm {
+			return d, nil
+		}
+		if err := p.transport.NegotiatedCapabilities().RequireFeature(FeaturePasswordConfirm); err != nil {
+			return nil, err
+		}
+		return d, nil
+
+	case daemon.ListPromptBody:
+		if d.PromptType == "autocomplete" && !p.transport.NegotiatedCapabilities().SupportsPrompt("autocomplete") {
+			d.PromptType = "list"
+		}
+		return d, nil
+
+	case daemon.EditorPromptBody:
+		if err := p.transport.NegotiatedCapabilities().RequirePrompt("editor"); err != nil {
+			return nil, err
+		}
+		return d, nil
+
+	case daemon.NumericPromptBody:
+		if !d.Float {
+			return d, nil
+		}
+		caps, err := p.transport.Capabilities(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err := caps.RequireFeature(FeatureNumberFloat); err != nil {
+			return nil, err
+		}
+		return d, nil
+
+	case daemon.DatetimePromptBody:
+		if d.Variant != DATETIME && !p.transport.NegotiatedCapabilities().SupportsFeature(FeatureDatetimeVariant) {
+			d.Variant = DATETIME
+		}
+		return d, nil
+
+	case daemon.DurationPromptBody:
+		caps, err := p.transport.Capabilities(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if caps.SupportsPrompt("duration") {
+			return d, nil
+		}
+		input := daemon.InputPromptBody{PromptEnvelope: d.PromptEnvelope, Default: d.Default}
+		input.PromptType = "input"
+		return input, nil
+	}
+	return definition, nil
+}
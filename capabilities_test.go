@@ -0,0 +1,284 @@
+package ctoai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// capabilitiesServer is a daemon reporting protocol version 0.9, without
+// autocomplete or editor prompts, password confirmation, forms or events
+func capabilitiesServer(t *testing.T) (*httptest.Server, func() []map[string]interface{}, func() int) {
+	var mu sync.Mutex
+	var prompts []map[string]interface{}
+	negotiations := 0
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch r.URL.Path {
+		case "/capabilities":
+			negotiations++
+			fmt.Fprint(w, `{"value": {
+				"version": "0.9",
+				"endpoints": ["capabilities", "prompt", "print"],
+				"prompts": ["input", "password", "list"],
+				"features": []
+			}}`)
+		case "/prompt":
+			var definition map[string]interface{}
+			if err := json.NewDecoder(r.Body).Decode(&definition); err != nil {
+				t.Errorf("Error in decoding request body: %v", err)
+			}
+			prompts = append(prompts, definition)
+
+			reply, _ := json.Marshal(map[string]interface{}{definition["name"].(string): "answer"})
+			if err := ioutil.WriteFile("/tmp/response-mocktest", reply, 0600); err != nil {
+				t.Errorf("Error writing reply file: %v", err)
+			}
+			fmt.Fprint(w, `{"replyFilename": "/tmp/response-mocktest"}`)
+		default:
+			t.Errorf("Error unexpected request to %s", r.URL.Path)
+		}
+	}))
+
+	return ts,
+		func() []map[string]interface{} {
+			mu.Lock()
+			defer mu.Unlock()
+			return prompts
+		},
+		func() int {
+			mu.Lock()
+			defer mu.Unlock()
+			return negotiations
+		}
+}
+
+func Test_Capabilities(t *testing.T) {
+	ts, _, negotiations := capabilitiesServer(t)
+	defer ts.Close()
+
+	c := NewClient(OptClientPort(ServerPort(t, ts)))
+	for i := 0; i < 2; i++ {
+		caps, err := c.Capabilities()
+		if err != nil {
+			t.Fatalf("Error getting capabilities: %v", err)
+		}
+		if caps.Version != "0.9" || !caps.Known() {
+			t.Errorf("Error unexpected capabilities: %+v", caps)
+		}
+		if caps.SupportsPrompt("editor") || !caps.SupportsPrompt("list") {
+			t.Errorf("Error unexpected prompt support: %+v", caps)
+		}
+	}
+	if n := negotiations(); n != 1 {
+		t.Errorf("Error expected capabilities to be fetched once, got %d", n)
+	}
+}
+
+func Test_Capabilities_Legacy(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !HandleCapabilities(w, r) {
+			t.Errorf("Error unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+
+	caps, err := NewClient(OptClientPort(ServerPort(t, ts))).Capabilities()
+	if err != nil {
+		t.Fatalf("Error getting capabilities: %v", err)
+	}
+	if caps.Known() || !caps.SupportsPrompt("editor") || !caps.SupportsFeature(FeaturePasswordConfirm) {
+		t.Errorf("Error legacy daemon should be assumed to support the baseline: %+v", caps)
+	}
+	if caps.SupportsEndpoint("prompts") || caps.SupportsPrompt("duration") || caps.SupportsFeature(FeatureNumberFloat) {
+		t.Errorf("Error legacy daemon should not be assumed to support new features: %+v", caps)
+	}
+}
+
+func Test_Capabilities_LegacyFallback(t *testing.T) {
+	script := &scriptedPrompts{answers: []interface{}{"api", "eu", float64(1), "45 mins", "2020-01-02T00:00:00Z", "prod", "3"}}
+	p := NewPrompt(OptClientHandler(script.handle))
+
+	answers, err := p.Form().Input("service", "Service?").Input("region", "Region?").Run()
+	if err != nil {
+		t.Fatalf("Error running form: %v", err)
+	}
+	if answers.String("service") != "api" || answers.String("region") != "eu" {
+		t.Errorf("Error unexpected form answers: %+v", answers)
+	}
+
+	if _, err := p.Float("cpu", "CPUs?"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Error expected unsupported float prompt, got: %v", err)
+	}
+
+	if _, err := p.Number("replicas", "Replicas?"); err != nil {
+		t.Errorf("Error in number prompt: %v", err)
+	}
+
+	d, err := p.Duration("window", "Window?")
+	if err != nil || d != 45*time.Minute {
+		t.Errorf("Error unexpected duration: %v, %v", d, err)
+	}
+
+	if _, err := p.Datetime("when", "When?", OptDatetimeVariant(DATE)); err != nil {
+		t.Errorf("Error in datetime prompt: %v", err)
+	}
+
+	cluster, err := p.ListChoices("cluster", "Cluster?", []Choice{{Label: "prod", Value: "c-1"}, {Label: "staging", Value: "c-2"}})
+	if err != nil || cluster != "c-1" {
+		t.Errorf("Error unexpected cluster: %v, %v", cluster, err)
+	}
+
+	n, err := p.Autocomplete("n", "Which number?", numbers, OptAutocompleteLimit(5))
+	if err != nil || n != 3 {
+		t.Errorf("Error unexpected autocomplete answer: %v, %v", n, err)
+	}
+
+	for _, endpoint := range script.endpoints {
+		if endpoint != "capabilities" && endpoint != "prompt" {
+			t.Errorf("Error unexpected request to %s", endpoint)
+		}
+	}
+	types := make([]interface{}, len(script.definitions))
+	for i, definition := range script.definitions {
+		types[i] = definition["type"]
+	}
+	expectedTypes := []interface{}{"input", "input", "number", "input", "datetime", "list", "autocomplete"}
+	if !reflect.DeepEqual(types, expectedTypes) {
+		t.Errorf("Error unexpected prompt types: %v", types)
+	}
+	if script.definitions[4]["variant"] != "date" {
+		t.Errorf("Error datetime variant should be kept: %v", script.definitions[4])
+	}
+	if !reflect.DeepEqual(script.definitions[5]["choices"], []interface{}{"prod", "staging"}) {
+		t.Errorf("Error choices should not be labeled: %v", script.definitions[5])
+	}
+	if script.definitions[6]["dynamic"] != nil {
+		t.Errorf("Error autocomplete should not be dynamic: %v", script.definitions[6])
+	}
+}
+
+func Test_Capabilities_Unsupported(t *testing.T) {
+	ts, _, _ := capabilitiesServer(t)
+	defer ts.Close()
+
+	c := NewClient(OptClientPort(ServerPort(t, ts)))
+
+	// Baseline prompt types and features are only checked once the
+	// capabilities have been negotiated
+	if _, err := c.Capabilities(); err != nil {
+		t.Fatalf("Error getting capabilities: %v", err)
+	}
+
+	_, err := c.Prompt.Editor("notes", "Notes?")
+	var unsupported *UnsupportedError
+	if !errors.Is(err, ErrUnsupported) || !errors.As(err, &unsupported) || unsupported.Feature != "prompt editor" {
+		t.Errorf("Error expected unsupported editor prompt, got: %v", err)
+	}
+
+	_, err = c.Prompt.Password("password", "Password?", OptPasswordConfirm(true))
+	if !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Error expected unsupported password confirmation, got: %v", err)
+	}
+
+	// Now that capabilities are known, unsupported endpoints fail without
+	// a request
+	_, err = c.Sdk.Events("", "")
+	if !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Error expected unsupported events endpoint, got: %v", err)
+	}
+}
+
+func Test_Capabilities_Fallback(t *testing.T) {
+	ts, prompts, _ := capabilitiesServer(t)
+	defer ts.Close()
+
+	c := NewClient(OptClientPort(ServerPort(t, ts)))
+	if _, err := c.Capabilities(); err != nil {
+		t.Fatalf("Error getting capabilities: %v", err)
+	}
+
+	answer, err := c.Prompt.List("region", "Region?", []string{"us", "eu"}, OptListAutocomplete(true))
+	if err != nil || answer != "answer" {
+		t.Errorf("Error unexpected answer: %v, %v", answer, err)
+	}
+
+	answers, err := c.Prompt.Form().
+		Input("name", "Name?").
+		List("zone", "Zone?", []string{"a", "b"}, OptListAutocomplete(true)).
+		Run()
+	if err != nil {
+		t.Fatalf("Error running form: %v", err)
+	}
+	if answers.String("name") != "answer" || answers.String("zone") != "answer" {
+		t.Errorf("Error unexpected form answers: %+v", answers)
+	}
+
+	sent := prompts()
+	if len(sent) != 3 {
+		t.Fatalf("Error expected 3 prompts, got %v", sent)
+	}
+	for _, definition := range []map[string]interface{}{sent[0], sent[2]} {
+		if definition["type"] != "list" {
+			t.Errorf("Error autocomplete prompt should fall back to list: %v", definition)
+		}
+	}
+}
+
+func Test_Capabilities_MiddlewareCalls(t *testing.T) {
+	var tracked []string
+	c := NewClient(OptClientHandler(func(ctx context.Context, req *DaemonRequest) (interface{}, error) {
+		switch req.Endpoint {
+		case "capabilities":
+			return fullCapabilities, nil
+		case "track":
+			tracked = append(tracked, req.Endpoint)
+			return nil, nil
+		}
+		return map[string]interface{}{"wait": "5m"}, nil
+	}))
+	var client Client
+	c.Use(func(next Handler) Handler {
+		return func(ctx context.Context, req *DaemonRequest) (interface{}, error) {
+			if req.Endpoint == "capabilities" {
+				// SDK calls made while negotiating must not wait for it
+				if err := client.Sdk.Track([]string{"audit"}, "capabilities", nil); err != nil {
+					t.Errorf("Error tracking: %v", err)
+				}
+				if _, err := client.Prompt.DurationContext(ctx, "wait", "How long?"); err != nil {
+					t.Errorf("Error in prompt request: %v", err)
+				}
+			}
+			return next(ctx, req)
+		}
+	})
+	client = c
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.Capabilities()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Error getting capabilities: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Error capabilities request blocked on a call from middleware")
+	}
+	if len(tracked) != 1 {
+		t.Errorf("Error expected one tracked event, got %v", tracked)
+	}
+}
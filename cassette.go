@@ -0,0 +1,280 @@
+package ctoai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/cto-ai/sdk-go/v2/internal/daemon"
+)
+
+// Interaction is a single recorded exchange with the daemon.
+//
+// Request and Response are generic JSON values; for async requests such as
+// prompts, Response holds the contents of the reply file. Secret values are
+// replaced with "********".
+type Interaction struct {
+	Kind     string            `json:"kind"`
+	Endpoint string            `json:"endpoint"`
+	Method   string            `json:"method"`
+	Request  interface{}       `json:"request"`
+	Response interface{}       `json:"response,omitempty"`
+	Error    *InteractionError `json:"error,omitempty"`
+}
+
+// InteractionError is a recorded error response
+type InteractionError struct {
+	Message    string `json:"message"`
+	StatusCode int    `json:"statusCode,omitempty"`
+	Code       string `json:"code,omitempty"`
+	Body       string `json:"body,omitempty"`
+}
+
+// Cassette is a recorded daemon session
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Recorder records daemon sessions into a cassette, e.g. to turn an
+// interactive run of an op into a regression test.
+//
+// Example:
+//
+//  recorder := ctoai.NewRecorder()
+//  client := ctoai.NewClient()
+//  client.Use(recorder.Middleware())
+//  // ... run the op ...
+//  err := recorder.Save("testdata/deploy.json")
+type Recorder struct {
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecorder creates an empty Recorder
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Middleware returns the middleware that records every request made
+// through a client.
+func (r *Recorder) Middleware() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *DaemonRequest) (interface{}, error) {
+			value, err := next(ctx, req)
+
+			body, response := daemon.Redact(req, value)
+			interaction := Interaction{
+				Kind:     req.Kind.String(),
+				Endpoint: req.Endpoint,
+				Method:   req.Method,
+				Request:  body,
+			}
+			if err != nil {
+				interaction.Error = newInteractionError(err)
+			} else {
+				interaction.Response = response
+			}
+
+			r.mu.Lock()
+			r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+			r.mu.Unlock()
+
+			return value, err
+		}
+	}
+}
+
+// Cassette returns the interactions recorded so far
+func (r *Recorder) Cassette() Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return Cassette{Interactions: append([]Interaction(nil), r.cassette.Interactions...)}
+}
+
+// Save writes the recorded cassette to the JSON file at path
+func (r *Recorder) Save(path string) error {
+	bytes, err := json.MarshalIndent(r.Cassette(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(bytes, '\n'), 0600)
+}
+
+func newInteractionError(err error) *InteractionError {
+	recorded := &InteractionError{Message: err.Error()}
+
+	var daemonErr *DaemonError
+	if errors.As(err, &daemonErr) {
+		recorded.StatusCode = daemonErr.StatusCode
+		recorded.Code = daemonErr.Code
+		recorded.Body = string(daemonErr.Body)
+	}
+	return recorded
+}
+
+// err recreates the recorded error, as a *DaemonError if it was one
+func (e *InteractionError) err(req *DaemonRequest) error {
+	if e.StatusCode == 0 {
+		return errors.New(e.Message)
+	}
+	return daemon.NewError(e.StatusCode, req.Endpoint, req.Method, []byte(e.Body))
+}
+
+// Replayer serves a recorded cassette in place of the daemon.
+//
+// Requests must arrive in the recorded order with the recorded bodies;
+// otherwise the request fails with an error showing the difference.
+//
+// Example:
+//
+//  replayer, err := ctoai.LoadCassette("testdata/deploy.json")
+//  if err != nil {
+//      t.Fatal(err)
+//  }
+//  client := ctoai.NewClient(ctoai.OptClientReplay(replayer))
+//  // ... run the op ...
+//  if err := replayer.Err(); err != nil {
+//      t.Fatal(err)
+//  }
+type Replayer struct {
+	mu       sync.Mutex
+	cassette Cassette
+	next     int
+	err      error
+}
+
+// LoadCassette reads a cassette saved by a Recorder
+func LoadCassette(path string) (*Replayer, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// Keep numbers as json.Number so that replayed answers are exact
+	decoder := json.NewDecoder(file)
+	decoder.UseNumber()
+	var cassette Cassette
+	if err := decoder.Decode(&cassette); err != nil {
+		return nil, fmt.Errorf("Error decoding cassette %s: %w", path, err)
+	}
+	return NewReplayer(cassette), nil
+}
+
+// NewReplayer creates a Replayer serving cassette
+func NewReplayer(cassette Cassette) *Replayer {
+	return &Replayer{cassette: cassette}
+}
+
+// OptClientReplay makes the client answer every request from replayer
+// instead of the daemon.
+func OptClientReplay(replayer *Replayer) ClientOption {
+	return OptClientHandler(replayer.handle)
+}
+
+// Err returns the first mismatch encountered during replay, or an error if
+// not all recorded interactions were replayed.
+func (r *Replayer) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return r.err
+	}
+	if r.next < len(r.cassette.Interactions) {
+		next := r.cassette.Interactions[r.next]
+		return fmt.Errorf("Replay ended after %d of %d interactions; next was %s %s", r.next, len(r.cassette.Interactions), next.Method, next.Endpoint)
+	}
+	return nil
+}
+
+func (r *Replayer) handle(ctx context.Context, req *DaemonRequest) (interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	body, _ := daemon.Redact(req, nil)
+	if r.next >= len(r.cassette.Interactions) {
+		r.err = fmt.Errorf("Unexpected request %s %s after the end of the cassette:\n%s", req.Method, req.Endpoint, jsonLines(body))
+		return nil, r.err
+	}
+
+	interaction := r.cassette.Interactions[r.next]
+	if interaction.Endpoint != req.Endpoint || interaction.Method != req.Method {
+		r.err = fmt.Errorf("Interaction %d: expected %s %s, got %s %s", r.next, interaction.Method, interaction.Endpoint, req.Method, req.Endpoint)
+		return nil, r.err
+	}
+
+	expected, actual := jsonLines(interaction.Request), jsonLines(body)
+	if expected != actual {
+		r.err = fmt.Errorf("Interaction %d: %s %s body differs from the recording (-recorded +actual):\n%s", r.next, req.Method, req.Endpoint, diffLines(expected, actual))
+		return nil, r.err
+	}
+
+	r.next++
+	req.StatusCode = http.StatusOK
+	if interaction.Error != nil {
+		req.StatusCode = interaction.Error.StatusCode
+		return nil, interaction.Error.err(req)
+	}
+	return interaction.Response, nil
+}
+
+// jsonLines renders a JSON value as indented JSON with sorted keys
+func jsonLines(value interface{}) string {
+	bytes, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(bytes)
+}
+
+// diffLines returns a line diff of a and b, marking removed lines with "-"
+// and added lines with "+"
+func diffLines(a, b string) string {
+	x, y := strings.Split(a, "\n"), strings.Split(b, "\n")
+
+	// lcs[i][j] is the length of the longest common subsequence of x[i:] and y[j:]
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var out strings.Builder
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			fmt.Fprintf(&out, "  %s\n", x[i])
+			i++
+			j++
+		case j < len(y) && (i == len(x) || lcs[i][j+1] >= lcs[i+1][j]):
+			fmt.Fprintf(&out, "+ %s\n", y[j])
+			j++
+		default:
+			fmt.Fprintf(&out, "- %s\n", x[i])
+			i++
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,162 @@
+package ctoai
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func recordSession(t *testing.T, path string) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/print":
+		case "/config/get":
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, `{"message": "no such key", "code": "not_found"}`)
+		case "/secret/get":
+			ioutil.WriteFile("/tmp/response-mocktest", []byte(`{"token": "hunter2"}`), 0600)
+			fmt.Fprintf(w, `{"replyFilename": "/tmp/response-mocktest"}`)
+		case "/prompt":
+			ioutil.WriteFile("/tmp/response-mocktest", []byte(`{"region": "eu"}`), 0600)
+			fmt.Fprintf(w, `{"replyFilename": "/tmp/response-mocktest"}`)
+		}
+	}))
+	defer ts.Close()
+
+	recorder := NewRecorder()
+	c := NewClient(OptClientPort(ServerPort(t, ts)))
+	c.Use(recorder.Middleware())
+
+	if err := c.Ux.Print("deploying"); err != nil {
+		t.Fatalf("Error printing test value: %v", err)
+	}
+	if _, err := c.Sdk.GetConfig("missing"); !IsNotFound(err) {
+		t.Fatalf("Error expected not found error, got: %v", err)
+	}
+	if _, err := c.Sdk.GetSecret("token"); err != nil {
+		t.Fatalf("Error in secret request: %v", err)
+	}
+	if _, err := c.Prompt.List("region", "Which region?", []string{"us", "eu"}); err != nil {
+		t.Fatalf("Error in prompt request: %v", err)
+	}
+
+	if err := recorder.Save(path); err != nil {
+		t.Fatalf("Error saving cassette: %v", err)
+	}
+}
+
+func Test_Cassette_RecordReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sdk-cassette")
+	if err != nil {
+		t.Fatalf("Error creating cassette dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "session.json")
+
+	recordSession(t, path)
+
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Error reading cassette: %v", err)
+	}
+	if strings.Contains(string(bytes), "hunter2") {
+		t.Errorf("Error secret recorded in cassette:\n%s", bytes)
+	}
+
+	replayer, err := LoadCassette(path)
+	if err != nil {
+		t.Fatalf("Error loading cassette: %v", err)
+	}
+	c := NewClient(OptClientReplay(replayer))
+
+	if err := c.Ux.Print("deploying"); err != nil {
+		t.Errorf("Error printing test value: %v", err)
+	}
+	if _, err := c.Sdk.GetConfig("missing"); !IsNotFound(err) {
+		t.Errorf("Error expected replayed not found error, got: %v", err)
+	}
+	if secret, err := c.Sdk.GetSecret("token"); err != nil || secret != "********" {
+		t.Errorf("Error unexpected replayed secret: %v, %v", secret, err)
+	}
+	if region, err := c.Prompt.List("region", "Which region?", []string{"us", "eu"}); err != nil || region != "eu" {
+		t.Errorf("Error unexpected replayed answer: %v, %v", region, err)
+	}
+
+	if err := replayer.Err(); err != nil {
+		t.Errorf("Error in replay: %v", err)
+	}
+}
+
+func Test_Cassette_ReplayMismatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sdk-cassette")
+	if err != nil {
+		t.Fatalf("Error creating cassette dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "session.json")
+
+	recordSession(t, path)
+
+	replayer, err := LoadCassette(path)
+	if err != nil {
+		t.Fatalf("Error loading cassette: %v", err)
+	}
+	c := NewClient(OptClientReplay(replayer))
+
+	err = c.Ux.Print("rolling back")
+	if err == nil {
+		t.Fatalf("Error expected mismatched print to fail")
+	}
+	for _, expected := range []string{`-   "text": "deploying"`, `+   "text": "rolling back"`} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Error diff missing %q:\n%v", expected, err)
+		}
+	}
+	if replayer.Err() == nil {
+		t.Errorf("Error replayer should report the mismatch")
+	}
+}
+
+func Test_Cassette_LargeIntegers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sdk-cassette")
+	if err != nil {
+		t.Fatalf("Error creating cassette dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "session.json")
+
+	// 2^53 + 1 is the smallest integer a float64 cannot represent
+	const large = int64(9007199254740993)
+	script := &scriptedPrompts{capabilities: fullCapabilities, answers: []interface{}{json.Number("9007199254740993")}}
+	recorder := NewRecorder()
+	c := NewClient(OptClientHandler(script.handle))
+	c.Use(recorder.Middleware())
+	if n, err := c.Prompt.Int64("id", "ID?"); err != nil || n != large {
+		t.Fatalf("Error recording answer: %v %v", n, err)
+	}
+	if err := recorder.Save(path); err != nil {
+		t.Fatalf("Error saving cassette: %v", err)
+	}
+
+	replayer, err := LoadCassette(path)
+	if err != nil {
+		t.Fatalf("Error loading cassette: %v", err)
+	}
+	c = NewClient(OptClientReplay(replayer))
+	n, err := c.Prompt.Int64("id", "ID?")
+	if err != nil {
+		t.Fatalf("Error in replayed prompt: %v", err)
+	}
+	if n != large {
+		t.Errorf("Error expected %d, got %d", large, n)
+	}
+	if err := replayer.Err(); err != nil {
+		t.Errorf("Error in replay: %v", err)
+	}
+}
@@ -0,0 +1,353 @@
+package ctoai
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/cto-ai/sdk-go/v2/internal/daemon"
+)
+
+// Choice is a choice of a ListChoices or CheckboxChoices prompt
+type Choice struct {
+	// Label is shown to the user
+	Label string
+	// Value is returned when the choice is selected, e.g. an ID. If nil,
+	// the Label is returned.
+	Value interface{}
+	// Description is shown next to the label
+	Description string
+	// Disabled choices are shown but cannot be selected
+	Disabled bool
+}
+
+// value returns the answer for selecting c
+func (c Choice) value() interface{} {
+	if c.Value == nil {
+		return c.Label
+	}
+	return c.Value
+}
+
+// choiceSet maps the choices of a prompt to the keys that stand for them in
+// the exchange with the daemon: their indexes if the daemon shows labeled
+// choices, and otherwise the text shown for them.
+type choiceSet struct {
+	choices []Choice
+	keys    []string
+	labeled bool
+
+	// pinned is the number of leading choices that replace keeps, and
+	// batch the number of choices it added last
+	pinned, batch int
+	// next is the key of the next choice added by replace
+	next int
+}
+
+// selection is the answer to a prompt with a choiceSet: the indexes of the
+// selected choices and their keys
+type selection struct {
+	indexes []int
+	keys    []string
+}
+
+func (p *Prompt) newChoiceSet(ctx context.Context, choices []Choice) (*choiceSet, error) {
+	caps, err := p.transport.Capabilities(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &choiceSet{choices: choices, keys: make([]string, len(choices)), labeled: caps.SupportsFeature(FeatureChoicesLabeled)}
+	seen := make(map[string]bool, len(choices))
+	for i, choice := range choices {
+		key := strconv.Itoa(i)
+		if !s.labeled {
+			key = choice.Label
+			if choice.Description != "" {
+				key = fmt.Sprintf("%s (%s)", choice.Label, choice.Description)
+			}
+			if seen[key] {
+				return nil, fmt.Errorf("More than one choice is shown as %s", key)
+			}
+			seen[key] = true
+		}
+		s.keys[i] = key
+	}
+	return s, nil
+}
+
+// labeledChoices returns the choices as sent to a daemon that shows labels,
+// or nil if the daemon does not
+func (s *choiceSet) labeledChoices() []daemon.LabeledChoice {
+	if !s.labeled {
+		return nil
+	}
+	labeled := make([]daemon.LabeledChoice, len(s.choices))
+	for i := range s.choices {
+		labeled[i] = s.labeledChoice(i)
+	}
+	return labeled
+}
+
+func (s *choiceSet) labeledChoice(i int) daemon.LabeledChoice {
+	return daemon.LabeledChoice{
+		Value:       s.keys[i],
+		Label:       s.choices[i].Label,
+		Description: s.choices[i].Description,
+		Disabled:    s.choices[i].Disabled,
+	}
+}
+
+// replace adds a batch of choices to a labeled set and returns them as sent
+// to the daemon. It drops the choices added before the previous batch,
+// other than pinned ones, so that the set does not grow while the daemon
+// is only showing the latest batch; the previous batch is kept for an
+// answer given just before the new one was shown. Keys are never reused.
+func (s *choiceSet) replace(choices []Choice) []daemon.LabeledChoice {
+	previous := len(s.choices) - s.batch
+	s.choices = append(append(s.choices[:s.pinned:s.pinned], s.choices[previous:]...), choices...)
+	s.keys = append(s.keys[:s.pinned:s.pinned], s.keys[previous:]...)
+	s.batch = len(choices)
+
+	labeled := make([]daemon.LabeledChoice, len(choices))
+	for i := range choices {
+		s.keys = append(s.keys, strconv.Itoa(s.next))
+		s.next++
+		labeled[i] = s.labeledChoice(len(s.keys) - 1)
+	}
+	return labeled
+}
+
+// pin makes replace keep the choices currently in the set
+func (s *choiceSet) pin() {
+	s.pinned, s.batch = len(s.choices), 0
+}
+
+// key returns the key of the choice with the given label or string value
+func (s *choiceSet) key(value string) (string, error) {
+	for i, choice := range s.choices {
+		if choice.Label == value || choice.Value == value {
+			return s.keys[i], nil
+		}
+	}
+	return "", fmt.Errorf("Default %s is not one of the choices", value)
+}
+
+// decode extracts the selection from the daemon's reply, whose keys are a
+// single string for a list prompt and an array for a checkbox prompt
+func (s *choiceSet) decode(body map[string]interface{}, name string) (interface{}, error) {
+	var keys []string
+	if _, ok := body[name].(string); ok {
+		key, _ := decodeString(body, name)
+		keys = []string{key}
+	} else {
+		var err error
+		if keys, err = decodeStrings(body, name); err != nil {
+			return nil, err
+		}
+	}
+
+	answer := selection{keys: keys}
+	for _, key := range keys {
+		i := indexOf(len(s.keys), func(i int) bool { return s.keys[i] == key })
+		if i < 0 {
+			return nil, fmt.Errorf("Daemon returned unknown choice %v", key)
+		}
+		answer.indexes = append(answer.indexes, i)
+	}
+	return answer, nil
+}
+
+// decodeOne is like decode for prompts that select a single choice, and
+// fails unless the daemon returned exactly one key
+func (s *choiceSet) decodeOne(body map[string]interface{}, name string) (interface{}, error) {
+	answer, err := s.decode(body, name)
+	if err != nil {
+		return nil, err
+	}
+	if keys := answer.(selection).keys; len(keys) != 1 {
+		return nil, fmt.Errorf("Daemon returned %d choices to %s, expected one", len(keys), name)
+	}
+	return answer, nil
+}
+
+// guard makes the validation of a prompt reject disabled choices and
+// selections breaking constraints before running validate, which gets the
+// selected label if single is set and the selected labels otherwise
+func (s *choiceSet) guard(envelope *daemon.PromptEnvelope, constraints daemon.SelectionConstraints, single bool) {
+	validate := envelope.Validate
+	envelope.Validate = func(answer interface{}) error {
+		selected, _ := answer.(selection)
+		labels := make([]string, len(selected.indexes))
+		for i, index := range selected.indexes {
+			if s.choices[index].Disabled {
+				return fmt.Errorf("%s cannot be selected", s.choices[index].Label)
+			}
+			labels[i] = s.choices[index].Label
+		}
+		if err := constraints.CheckSelected(len(selected.indexes)); err != nil {
+			return err
+		}
+		switch {
+		case validate == nil:
+			return nil
+		case single:
+			return validate(labels[0])
+		}
+		return validate(labels)
+	}
+}
+
+// values returns the values of the selected choices
+func (s *choiceSet) values(selected selection) []interface{} {
+	values := make([]interface{}, len(selected.indexes))
+	for i, index := range selected.indexes {
+		values[i] = s.choices[index].value()
+	}
+	return values
+}
+
+// ListChoices presents a list of labeled choices to the user to select one
+// from in the interface (i.e. terminal or slack), like List, and returns
+// the Value of the selected choice.
+//
+// Choices are shown with their labels and descriptions, and disabled
+// choices cannot be selected. Daemons that do not support
+// FeatureChoicesLabeled show each choice as its label followed by its
+// description in parentheses, and the SDK rejects disabled choices. A
+// default set with OptListDefaultValue is matched against the labels and
+// string values of the choices.
+//
+// Example:
+//
+//  p := ctoai.NewPrompt()
+//  clusterID, err := p.ListChoices("cluster", "Which cluster?", []ctoai.Choice{
+//      {Label: "prod-cluster", Value: "c-1234", Description: "us-east-1, 42 nodes"},
+//      {Label: "staging-cluster", Value: "c-5678", Description: "eu-west-1, 3 nodes"},
+//      {Label: "old-cluster", Value: "c-0001", Description: "decommissioned", Disabled: true},
+//  }) // user selects prod-cluster
+//  if err != nil {
+//      panic(err)
+//  }
+//
+//  fmt.Println(clusterID)
+//
+// Output:
+// c-1234
+func (p *Prompt) ListChoices(name, msg string, choices []Choice, options ...ListOption) (interface{}, error) {
+	return p.ListChoicesContext(context.Background(), name, msg, choices, options...)
+}
+
+// ListChoicesContext is like ListChoices but uses ctx to cancel or time out
+// the daemon request.
+func (p *Prompt) ListChoicesContext(ctx context.Context, name, msg string, choices []Choice, options ...ListOption) (interface{}, error) {
+	s, err := p.newChoiceSet(ctx, choices)
+	if err != nil {
+		return nil, err
+	}
+
+	definition := newListDefinition(name, msg, s.keys, options)
+	definition.Labeled = s.labeledChoices()
+	if definition.DefaultSet && definition.DefaultIsValue {
+		if definition.DefaultValue, err = s.key(definition.DefaultValue); err != nil {
+			return nil, err
+		}
+	}
+	s.guard(&definition.PromptEnvelope, daemon.SelectionConstraints{}, true)
+
+	answer, err := p.askValidated(ctx, definition, name, s.decodeOne)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.values(answer.(selection))[0], nil
+}
+
+// CheckboxChoices presents a list of labeled choices to the user, who can
+// select several of them in the interface (i.e. terminal or slack), like
+// Checkbox, and returns the Values of the selected choices; see
+// ListChoices.
+func (p *Prompt) CheckboxChoices(name, msg string, choices []Choice, options ...CheckboxOption) ([]interface{}, error) {
+	return p.CheckboxChoicesContext(context.Background(), name, msg, choices, options...)
+}
+
+// CheckboxChoicesContext is like CheckboxChoices but uses ctx to cancel or
+// time out the daemon request.
+func (p *Prompt) CheckboxChoicesContext(ctx context.Context, name, msg string, choices []Choice, options ...CheckboxOption) ([]interface{}, error) {
+	s, selected, err := p.checkboxSelection(ctx, name, msg, choices, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.values(selected), nil
+}
+
+// CheckboxIndexes presents a list of options to the user, who can select
+// multiple items in the interface (i.e. terminal or slack), like Checkbox,
+// and returns the indexes of the selected choices in choices along with
+// the choices themselves. Duplicate choices are told apart if the daemon
+// supports FeatureChoicesLabeled, and are rejected otherwise.
+//
+// Example:
+//
+//  p := ctoai.NewPrompt()
+//  indexes, services, err := p.CheckboxIndexes("services", "Which services should be deployed?", []string{"api", "worker", "web"}, ctoai.OptCheckboxRequired()) // user selects api and web
+//  if err != nil {
+//      panic(err)
+//  }
+//
+//  fmt.Println(indexes, services)
+//
+// Output:
+// [0 2] [api web]
+func (p *Prompt) CheckboxIndexes(name, msg string, choices []string, options ...CheckboxOption) ([]int, []string, error) {
+	return p.CheckboxIndexesContext(context.Background(), name, msg, choices, options...)
+}
+
+// CheckboxIndexesContext is like CheckboxIndexes but uses ctx to cancel or
+// time out the daemon request.
+func (p *Prompt) CheckboxIndexesContext(ctx context.Context, name, msg string, choices []string, options ...CheckboxOption) ([]int, []string, error) {
+	labeled := make([]Choice, len(choices))
+	for i, choice := range choices {
+		labeled[i] = Choice{Label: choice}
+	}
+
+	_, selected, err := p.checkboxSelection(ctx, name, msg, labeled, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	values := make([]string, len(selected.indexes))
+	for i, index := range selected.indexes {
+		values[i] = choices[index]
+	}
+	return selected.indexes, values, nil
+}
+
+// checkboxSelection asks a checkbox prompt with a choiceSet
+func (p *Prompt) checkboxSelection(ctx context.Context, name, msg string, choices []Choice, options []CheckboxOption) (*choiceSet, selection, error) {
+	s, err := p.newChoiceSet(ctx, choices)
+	if err != nil {
+		return nil, selection{}, err
+	}
+
+	definition := newCheckboxDefinition(name, msg, s.keys, options)
+	definition.Labeled = s.labeledChoices()
+	if definition.DefaultSet && definition.DefaultIsValue {
+		keys := make([]string, len(definition.DefaultValue))
+		for i, value := range definition.DefaultValue {
+			if keys[i], err = s.key(value); err != nil {
+				return nil, selection{}, err
+			}
+		}
+		definition.DefaultValue = keys
+	}
+	s.guard(&definition.PromptEnvelope, definition.SelectionConstraints, false)
+
+	answer, err := p.askValidated(ctx, definition, name, s.decode)
+	if err != nil {
+		return nil, selection{}, err
+	}
+
+	return s, answer.(selection), nil
+}
@@ -0,0 +1,177 @@
+package ctoai
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/cto-ai/sdk-go/v2/internal/daemon"
+)
+
+var clusters = []Choice{
+	{Label: "prod", Value: "c-1", Description: "us-east-1"},
+	{Label: "staging", Value: "c-2"},
+	{Label: "old", Value: "c-0", Description: "decommissioned", Disabled: true},
+}
+
+func Test_ListChoices_Labeled(t *testing.T) {
+	script := &scriptedPrompts{capabilities: fullCapabilities, answers: []interface{}{"2", "0"}}
+	p := NewPrompt(OptClientHandler(script.handle))
+
+	cluster, err := p.ListChoices("cluster", "Which cluster?", clusters, OptListDefaultValue("staging"))
+	if err != nil {
+		t.Fatalf("Error in prompt request: %v", err)
+	}
+	if cluster != "c-1" {
+		t.Errorf("Error unexpected answer: %v", cluster)
+	}
+
+	expectedChoices := []interface{}{
+		map[string]interface{}{"value": "0", "label": "prod", "description": "us-east-1"},
+		map[string]interface{}{"value": "1", "label": "staging"},
+		map[string]interface{}{"value": "2", "label": "old", "description": "decommissioned", "disabled": true},
+	}
+	if !reflect.DeepEqual(script.definitions[0]["choices"], expectedChoices) {
+		t.Errorf("Error unexpected choices: %v", script.definitions[0]["choices"])
+	}
+	if script.definitions[0]["default"] != "1" || script.definitions[1]["default"] != "2" {
+		t.Errorf("Error unexpected defaults: %v, %v", script.definitions[0]["default"], script.definitions[1]["default"])
+	}
+	if !reflect.DeepEqual(script.prints, []string{"old cannot be selected"}) {
+		t.Errorf("Error unexpected prints: %v", script.prints)
+	}
+}
+
+func Test_ListChoices_Unlabeled(t *testing.T) {
+	var caps interface{}
+	json.Unmarshal([]byte(`{"version": "1", "endpoints": ["prompt", "print"], "prompts": ["list"], "features": []}`), &caps)
+	script := &scriptedPrompts{capabilities: caps, answers: []interface{}{"staging"}}
+	p := NewPrompt(OptClientHandler(script.handle))
+
+	cluster, err := p.ListChoices("cluster", "Which cluster?", clusters, OptListDefaultValue("c-1"))
+	if err != nil {
+		t.Fatalf("Error in prompt request: %v", err)
+	}
+	if cluster != "c-2" {
+		t.Errorf("Error unexpected answer: %v", cluster)
+	}
+
+	expectedChoices := []interface{}{"prod (us-east-1)", "staging", "old (decommissioned)"}
+	if !reflect.DeepEqual(script.definitions[0]["choices"], expectedChoices) {
+		t.Errorf("Error unexpected choices: %v", script.definitions[0]["choices"])
+	}
+	if script.definitions[0]["default"] != "prod (us-east-1)" {
+		t.Errorf("Error unexpected default: %v", script.definitions[0]["default"])
+	}
+}
+
+func Test_CheckboxChoices(t *testing.T) {
+	script := &scriptedPrompts{capabilities: fullCapabilities, answers: []interface{}{[]interface{}{"0", "1"}}}
+	p := NewPrompt(OptClientHandler(script.handle))
+
+	var selected []string
+	validate := func(labels []string) error {
+		selected = labels
+		return nil
+	}
+	values, err := p.CheckboxChoices("clusters", "Which clusters?", clusters, OptCheckboxValidate(validate))
+	if err != nil {
+		t.Fatalf("Error in prompt request: %v", err)
+	}
+	if !reflect.DeepEqual(values, []interface{}{"c-1", "c-2"}) {
+		t.Errorf("Error unexpected answer: %v", values)
+	}
+	if !reflect.DeepEqual(selected, []string{"prod", "staging"}) {
+		t.Errorf("Error unexpected labels validated: %v", selected)
+	}
+}
+
+func Test_ListChoices_UnknownDefault(t *testing.T) {
+	script := &scriptedPrompts{}
+	p := NewPrompt(OptClientHandler(script.handle))
+
+	if _, err := p.ListChoices("cluster", "Which cluster?", clusters, OptListDefaultValue("dev")); err == nil {
+		t.Errorf("Error expected an unknown default to fail")
+	}
+	if len(script.definitions) != 0 {
+		t.Errorf("Error unexpected prompts: %v", script.definitions)
+	}
+}
+
+func Test_ListChoices_NotOneSelected(t *testing.T) {
+	for _, answer := range []interface{}{[]interface{}{}, []interface{}{"0", "1"}} {
+		script := &scriptedPrompts{capabilities: fullCapabilities, answers: []interface{}{answer}}
+		p := NewPrompt(OptClientHandler(script.handle))
+
+		if cluster, err := p.ListChoices("cluster", "Which cluster?", clusters); err == nil {
+			t.Errorf("Error expected answer %v to fail, got: %v", answer, cluster)
+		}
+	}
+}
+
+func Test_Checkbox_Required(t *testing.T) {
+	script := &scriptedPrompts{answers: []interface{}{[]interface{}{}, []interface{}{"api", "web", "worker"}, []interface{}{"api"}}}
+	p := NewPrompt(OptClientHandler(script.handle))
+
+	services, err := p.Checkbox("services", "Deploy?", []string{"api", "web", "worker"}, OptCheckboxRequired(), OptCheckboxMax(2))
+	if err != nil {
+		t.Fatalf("Error in prompt request: %v", err)
+	}
+	if !reflect.DeepEqual(services, []string{"api"}) {
+		t.Errorf("Error unexpected answer: %v", services)
+	}
+
+	if script.definitions[0]["minSelected"] != 1.0 || script.definitions[0]["maxSelected"] != 2.0 {
+		t.Errorf("Error unexpected constraints: %v", script.definitions[0])
+	}
+	expectedPrints := []string{"Select at least one choice", "Select at most 2 choices"}
+	if !reflect.DeepEqual(script.prints, expectedPrints) {
+		t.Errorf("Error unexpected prints: %v", script.prints)
+	}
+}
+
+func Test_CheckboxIndexes(t *testing.T) {
+	script := &scriptedPrompts{capabilities: fullCapabilities, answers: []interface{}{[]interface{}{}, []interface{}{"0", "2"}}}
+	p := NewPrompt(OptClientHandler(script.handle))
+
+	indexes, services, err := p.CheckboxIndexes("services", "Deploy?", []string{"api", "web", "api"}, OptCheckboxMin(1), OptCheckboxDefaultValues([]string{"web"}))
+	if err != nil {
+		t.Fatalf("Error in prompt request: %v", err)
+	}
+	if !reflect.DeepEqual(indexes, []int{0, 2}) || !reflect.DeepEqual(services, []string{"api", "api"}) {
+		t.Errorf("Error unexpected answer: %v, %v", indexes, services)
+	}
+
+	if !reflect.DeepEqual(script.definitions[0]["default"], []interface{}{"1"}) {
+		t.Errorf("Error unexpected default: %v", script.definitions[0]["default"])
+	}
+	if !reflect.DeepEqual(script.prints, []string{"Select at least one choice"}) {
+		t.Errorf("Error unexpected prints: %v", script.prints)
+	}
+}
+
+func Test_ChoiceSet_Replace(t *testing.T) {
+	s := &choiceSet{labeled: true}
+	s.replace([]Choice{{Label: "a"}, {Label: "b"}})
+	s.pin()
+
+	var last []daemon.LabeledChoice
+	for i := 0; i < 5; i++ {
+		last = s.replace([]Choice{{Label: fmt.Sprintf("c%d", i)}, {Label: fmt.Sprintf("d%d", i)}})
+	}
+
+	labels := make([]string, len(s.choices))
+	for i, choice := range s.choices {
+		labels[i] = choice.Label
+	}
+	if !reflect.DeepEqual(labels, []string{"a", "b", "c3", "d3", "c4", "d4"}) {
+		t.Errorf("Error unexpected choices kept: %v", labels)
+	}
+	if !reflect.DeepEqual(s.keys, []string{"0", "1", "8", "9", "10", "11"}) {
+		t.Errorf("Error unexpected keys: %v", s.keys)
+	}
+	if last[0].Value != "10" || last[1].Label != "d4" {
+		t.Errorf("Error unexpected choices sent: %v", last)
+	}
+}
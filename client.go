@@ -1,17 +1,263 @@
 package ctoai
 
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/cto-ai/sdk-go/v2/internal/daemon"
+)
+
 // Client is the top-level client for Ops Platform services
 type Client struct {
-	Prompt *Prompt
-	Ux     *Ux
-	Sdk    *Sdk
+	Prompt Prompter
+	Ux     UX
+	Sdk    Platform
+
+	transport *daemon.Client
+}
+
+// ClientOption is an option for the NewClient function
+type ClientOption func(*clientOptions)
+
+// clientOptions holds the settings built up by ClientOptions
+type clientOptions struct {
+	transport   []daemon.Option
+	queuePrints bool
+	attempts    int
+	prompt      Prompter
+	ux          UX
+	sdk         Platform
+}
+
+// transportOption wraps an option of the daemon transport
+func transportOption(option daemon.Option) ClientOption {
+	return func(o *clientOptions) {
+		o.transport = append(o.transport, option)
+	}
+}
+
+func newClientOptions(options []ClientOption) *clientOptions {
+	o := &clientOptions{}
+	for _, option := range options {
+		option(o)
+	}
+	return o
+}
+
+// OptClientHost sets the host of the daemon. Defaults to 127.0.0.1.
+func OptClientHost(host string) ClientOption {
+	return transportOption(daemon.WithHost(host))
+}
+
+// OptClientPort sets the port of the daemon.
+//
+// If unset, the port is read from the SDK_SPEAK_PORT environment variable
+// on every request.
+func OptClientPort(port int) ClientOption {
+	return transportOption(daemon.WithPort(port))
+}
+
+// OptClientSocket makes the client talk to the daemon over the Unix domain
+// socket at path instead of TCP.
+//
+// If neither a socket nor a port is set, the socket path is read from the
+// SDK_SPEAK_SOCKET environment variable, falling back to SDK_SPEAK_PORT.
+// When a custom RoundTripper is set, it is responsible for dialing the
+// socket itself.
+func OptClientSocket(path string) ClientOption {
+	return transportOption(daemon.WithSocket(path))
+}
+
+// OptClientHTTPClient sets the http.Client used to talk to the daemon.
+func OptClientHTTPClient(httpClient *http.Client) ClientOption {
+	return transportOption(daemon.WithHTTPClient(httpClient))
+}
+
+// OptClientRoundTripper sets the http.RoundTripper used to talk to the
+// daemon, e.g. to put a proxy in front of it.
+func OptClientRoundTripper(rt http.RoundTripper) ClientOption {
+	return transportOption(daemon.WithRoundTripper(rt))
+}
+
+// RetryPolicy controls how failed daemon requests are retried; see
+// OptClientRetry.
+type RetryPolicy = daemon.RetryPolicy
+
+// DefaultRetryPolicy returns a retry policy suited to waiting for a daemon
+// that is still starting up.
+func DefaultRetryPolicy() RetryPolicy {
+	return daemon.DefaultRetryPolicy()
+}
+
+// OptClientRetry enables retrying daemon requests that fail transiently,
+// with exponential backoff and jitter.
+//
+// Requests that never reached the daemon are always retried. Reads such as
+// config/get, state/get-all, user, team and events are also retried after
+// connection resets and 5xx responses; prints, tracking, prompts and
+// secrets are not, so that the user never sees them twice. The
+// policy's Idempotent map overrides this per endpoint.
+//
+// Retries are disabled by default.
+func OptClientRetry(policy RetryPolicy) ClientOption {
+	return transportOption(daemon.WithRetry(policy))
+}
+
+// OptClientReplyDir sets the directory the daemon writes reply files into.
+// Reply files named by the daemon outside it are rejected with
+// ErrUnsafeReplyFile. Defaults to os.TempDir().
+func OptClientReplyDir(dir string) ClientOption {
+	return transportOption(daemon.WithReplyDir(dir))
+}
+
+// OptClientReplyTimeout sets how long to wait for the daemon to finish
+// writing a reply file after answering a prompt or secret request.
+// Defaults to 30 seconds.
+func OptClientReplyTimeout(timeout time.Duration) ClientOption {
+	return transportOption(daemon.WithReplyTimeout(timeout))
+}
+
+// OptClientReplyMaxSize sets the largest reply file that will be read, in
+// bytes. Defaults to 10 MiB.
+func OptClientReplyMaxSize(size int64) ClientOption {
+	return transportOption(daemon.WithReplyMaxSize(size))
+}
+
+// OptClientDebug logs every daemon exchange to w: endpoint, method, request
+// body, status, response or reply-file contents and timing. Secret values
+// and answers to secret and password prompts are masked.
+//
+// Setting the SDK_DEBUG environment variable to a true value such as "1"
+// enables the same logging on stderr.
+func OptClientDebug(w io.Writer) ClientOption {
+	return transportOption(daemon.WithDebug(w))
+}
+
+// DaemonRequest describes a single call to the daemon, as seen by
+// middleware.
+type DaemonRequest = daemon.Request
+
+// DaemonRequestKind identifies how the daemon answers a request
+type DaemonRequestKind = daemon.RequestKind
+
+// The kinds of daemon request
+const (
+	// RequestSimple requests, such as print and track, have no response value
+	RequestSimple = daemon.Simple
+	// RequestSync requests, such as config/get, respond with a value directly
+	RequestSync = daemon.Sync
+	// RequestAsync requests, such as prompt and secret/get, respond with a
+	// map[string]interface{} read from a reply file
+	RequestAsync = daemon.Async
+)
+
+// Handler performs a daemon request, returning nil for RequestSimple, the
+// value for RequestSync and a map[string]interface{} for RequestAsync.
+type Handler = daemon.Handler
+
+// Middleware wraps a Handler to observe or alter daemon requests, e.g. for
+// audit logging, metrics, redaction or fault injection.
+type Middleware = daemon.Middleware
+
+// NewClient creates an Ops Platform client with all services included.
+//
+// All services share a single daemon transport, configured by options.
+func NewClient(options ...ClientOption) Client {
+	o := newClientOptions(options)
+	transport := newTransport(o)
+	c := Client{
+		Prompt: o.prompt,
+		Ux:     o.ux,
+		Sdk:    o.sdk,
+
+		transport: transport,
+	}
+	if c.Ux == nil {
+		c.Ux = newUx(transport, o)
+	}
+	if c.Prompt == nil {
+		c.Prompt = newPrompt(transport, c.Ux, o)
+	}
+	if c.Sdk == nil {
+		c.Sdk = &Sdk{transport: transport}
+	}
+	return c
+}
+
+// OptClientPrompter makes the client use p for its Prompt service instead
+// of prompting through the daemon.
+func OptClientPrompter(p Prompter) ClientOption {
+	return func(o *clientOptions) {
+		o.prompt = p
+	}
+}
+
+// OptClientUX makes the client use u for its Ux service instead of
+// rendering through the daemon.
+func OptClientUX(u UX) ClientOption {
+	return func(o *clientOptions) {
+		o.ux = u
+	}
+}
+
+// OptClientPlatform makes the client use s for its Sdk service instead of
+// the daemon.
+func OptClientPlatform(s Platform) ClientOption {
+	return func(o *clientOptions) {
+		o.sdk = s
+	}
 }
 
-// NewClient creates an Ops Platform client with all services included
-func NewClient() Client {
-	return Client{
-		Prompt: NewPrompt(),
-		Ux:     NewUx(),
-		Sdk:    NewSdk(),
+// OptClientQueuePrints makes Ux.Print hold back text printed while a
+// spinner or progress bar is shown, and print it in order once none is.
+// By default, text is printed right away, above the spinner or progress
+// bar.
+func OptClientQueuePrints(enabled bool) ClientOption {
+	return func(o *clientOptions) {
+		o.queuePrints = enabled
 	}
 }
+
+// OptClientHandler makes the client serve every request with h instead of
+// sending it to the daemon, e.g. to answer requests from memory in tests.
+// Middleware registered with Use still runs around h.
+func OptClientHandler(h Handler) ClientOption {
+	return transportOption(daemon.WithFallback(h, true))
+}
+
+// Use registers middleware that sees every request made by the client's
+// Prompt, Ux and Sdk services. The first middleware registered is the
+// outermost. Services set with OptClientPrompter, OptClientUX or
+// OptClientPlatform do not go through the middleware, nor do the polls
+// for the query typed into an Autocomplete prompt, sent many times a
+// second.
+//
+// Example:
+//
+//  client := ctoai.NewClient()
+//  client.Use(func(next ctoai.Handler) ctoai.Handler {
+//      return func(ctx context.Context, req *ctoai.DaemonRequest) (interface{}, error) {
+//          start := time.Now()
+//          value, err := next(ctx, req)
+//          log.Printf("%s %s took %v (err: %v)", req.Method, req.Endpoint, time.Since(start), err)
+//          return value, err
+//      }
+//  })
+func (c Client) Use(middleware ...Middleware) {
+	c.transport.Use(middleware...)
+}
+
+// Ping checks whether the SDK daemon can be reached. The returned error
+// wraps ErrDaemonUnavailable if it cannot. In offline mode or with a
+// handler set with OptClientHandler, Ping asks them instead.
+func (c Client) Ping() error {
+	return c.PingContext(context.Background())
+}
+
+// PingContext is like Ping but uses ctx to cancel or time out the daemon
+// request.
+func (c Client) PingContext(ctx context.Context) error {
+	return c.transport.Ping(ctx)
+}
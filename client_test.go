@@ -0,0 +1,112 @@
+package ctoai
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func ServerPort(t *testing.T, ts *httptest.Server) int {
+	_, portStr, err := net.SplitHostPort(ts.URL[7:])
+	if err != nil {
+		t.Fatalf("Error splitting host port: %s", err)
+	}
+
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		t.Fatalf("Error parsing port: %s", err)
+	}
+	return port
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func Test_NewClient_SideBySide(t *testing.T) {
+	var first, second int
+	ts1 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ValidateRequest(t, r, "/print")
+		first++
+	}))
+	defer ts1.Close()
+
+	ts2 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ValidateRequest(t, r, "/print")
+		second++
+	}))
+	defer ts2.Close()
+
+	c1 := NewClient(OptClientPort(ServerPort(t, ts1)))
+	c2 := NewClient(OptClientHost("localhost"), OptClientPort(ServerPort(t, ts2)))
+
+	if err := c1.Ux.Print("one"); err != nil {
+		t.Errorf("Error printing test value: %v", err)
+	}
+	if err := c2.Ux.Print("two"); err != nil {
+		t.Errorf("Error printing test value: %v", err)
+	}
+	if err := c2.Ux.Print("three"); err != nil {
+		t.Errorf("Error printing test value: %v", err)
+	}
+
+	if first != 1 || second != 2 {
+		t.Errorf("Error unexpected request counts: %d, %d", first, second)
+	}
+}
+
+func Test_NewClient_RoundTripper(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ValidateRequest(t, r, "/print")
+		if r.Header.Get("X-Proxy") != "yes" {
+			t.Errorf("Error request did not pass through round tripper")
+		}
+	}))
+	defer ts.Close()
+
+	rt := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		r.Header.Set("X-Proxy", "yes")
+		return http.DefaultTransport.RoundTrip(r)
+	})
+
+	c := NewClient(OptClientPort(ServerPort(t, ts)), OptClientRoundTripper(rt))
+	if err := c.Ux.Print("test"); err != nil {
+		t.Errorf("Error printing test value: %v", err)
+	}
+
+	// The round tripper applies to the http.Client set by either option
+	// order, without modifying it
+	httpClient := &http.Client{Timeout: time.Minute}
+	for _, options := range [][]ClientOption{
+		{OptClientHTTPClient(httpClient), OptClientRoundTripper(rt)},
+		{OptClientRoundTripper(rt), OptClientHTTPClient(httpClient)},
+	} {
+		c := NewClient(append(options, OptClientPort(ServerPort(t, ts)))...)
+		if err := c.Ux.Print("test"); err != nil {
+			t.Errorf("Error printing test value: %v", err)
+		}
+	}
+	if httpClient.Transport != nil {
+		t.Errorf("Error the http.Client should not be modified")
+	}
+}
+
+func Test_ZeroValueServices(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ValidateRequest(t, r, "/print")
+	}))
+	defer ts.Close()
+
+	SetPortVar(t, ts)
+
+	u := Ux{}
+	if err := u.Print("test"); err != nil {
+		t.Errorf("Error printing test value: %v", err)
+	}
+
+}
@@ -0,0 +1,54 @@
+package ctoai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_ContextDeadline_PromptInput(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ValidateRequest(t, r, "/prompt")
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+
+	defer ts.Close()
+	defer close(release)
+
+	SetPortVar(t, ts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	p := NewPrompt()
+	_, err := p.InputContext(ctx, "test", "type test")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Error expected deadline exceeded, got: %v", err)
+	}
+}
+
+func Test_ContextCancel_GetConfig(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Error request should not reach the daemon: %v", r.URL.Path)
+	}))
+
+	defer ts.Close()
+
+	SetPortVar(t, ts)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	s := NewSdk()
+	_, err := s.GetConfigContext(ctx, "test-key")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Error expected context canceled, got: %v", err)
+	}
+}
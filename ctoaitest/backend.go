@@ -0,0 +1,531 @@
+package ctoaitest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	ctoai "github.com/cto-ai/sdk-go/v2"
+	"github.com/cto-ai/sdk-go/v2/internal/daemon"
+)
+
+type failure struct {
+	status int
+	body   string
+}
+
+// backend holds the state of a fake daemon and serves its endpoints. It is
+// shared by the HTTP Daemon and the in-memory fakes.
+type backend struct {
+	mu       sync.Mutex
+	caps     ctoai.Capabilities
+	answers  map[string][]interface{}
+	queries  map[string][]string
+	searches map[string]*search
+	failures map[string][]failure
+	config   map[string]string
+	state    map[string]interface{}
+	secrets  map[string]string
+	events   []map[string]interface{}
+	user     ctoai.UserInfo
+	team     ctoai.TeamInfo
+	requests []Request
+	prompts  []map[string]interface{}
+	prints   []string
+	tracked  []Event
+}
+
+func newBackend() *backend {
+	return &backend{
+		caps:     daemon.FullCapabilities(daemon.ProtocolVersion),
+		answers:  make(map[string][]interface{}),
+		queries:  make(map[string][]string),
+		searches: make(map[string]*search),
+		failures: make(map[string][]failure),
+		config:   make(map[string]string),
+		state:    make(map[string]interface{}),
+		secrets:  make(map[string]string),
+		events:   []map[string]interface{}{},
+		user:     ctoai.UserInfo{ID: "test-user", Username: "test", Email: "test@example.com"},
+		team:     ctoai.TeamInfo{ID: "test-team", Name: "test"},
+	}
+}
+
+// httpError is an error response sent by the daemon
+type httpError struct {
+	status  int
+	message string
+}
+
+func (e *httpError) Error() string {
+	return e.message
+}
+
+// serve records a request and handles it, unless a failure is queued for
+// its endpoint. It returns the response value, whether the value belongs
+// in a reply file, and the queued failure if there was one.
+//
+// A dynamic autocomplete prompt is answered once the SDK has served the
+// choices for every query typed into it, or fails when ctx is done.
+func (b *backend) serve(ctx context.Context, endpoint, method string, body map[string]interface{}) (interface{}, bool, *failure, error) {
+	b.mu.Lock()
+	b.requests = append(b.requests, Request{Endpoint: endpoint, Method: method, Body: body})
+	if failures := b.failures[endpoint]; len(failures) > 0 {
+		b.failures[endpoint] = failures[1:]
+		b.mu.Unlock()
+		return nil, false, &failures[0], nil
+	}
+	value, async, err := b.handle(endpoint, body)
+	b.mu.Unlock()
+
+	if s, ok := value.(*search); ok {
+		value, err = b.awaitSearch(ctx, s)
+	}
+	return value, async, nil, err
+}
+
+// handler serves requests in memory, in place of the daemon
+func (b *backend) handler(ctx context.Context, req *ctoai.DaemonRequest) (interface{}, error) {
+	body := make(map[string]interface{})
+	if req.Body != nil {
+		if err := roundTrip(req.Body, &body); err != nil {
+			return nil, err
+		}
+	}
+
+	value, _, failed, err := b.serve(ctx, req.Endpoint, req.Method, body)
+	if failed != nil {
+		req.StatusCode = failed.status
+		return nil, daemon.NewError(failed.status, req.Endpoint, req.Method, []byte(failed.body))
+	}
+	if httpErr, ok := err.(*httpError); ok {
+		req.StatusCode = httpErr.status
+		message, _ := json.Marshal(map[string]string{"message": httpErr.message})
+		return nil, daemon.NewError(httpErr.status, req.Endpoint, req.Method, message)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	req.StatusCode = http.StatusOK
+	if value == nil {
+		return nil, nil
+	}
+
+	// Return the value as it would be decoded from the daemon's JSON, with
+	// reply file numbers kept exact
+	if req.Kind == ctoai.RequestAsync {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("Error marshalling JSON: %w", err)
+		}
+		var response map[string]interface{}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		err = decoder.Decode(&response)
+		return response, err
+	}
+	var response interface{}
+	err = roundTrip(value, &response)
+	return response, err
+}
+
+// handle serves a request, returning its value and whether the value
+// belongs in a reply file; b.mu must be held
+func (b *backend) handle(endpoint string, body map[string]interface{}) (interface{}, bool, error) {
+	key, _ := body["key"].(string)
+
+	switch endpoint {
+	case "capabilities":
+		return b.caps, false, nil
+	case "prompt":
+		name, _ := body["name"].(string)
+		if dynamic, _ := body["dynamic"].(bool); dynamic {
+			return b.startSearch(body), true, nil
+		}
+		answer, err := b.ask(body)
+		if err != nil {
+			return nil, false, err
+		}
+		return map[string]interface{}{name: answer}, true, nil
+	case "prompts":
+		prompts, _ := body["prompts"].([]interface{})
+		answers := make(map[string]interface{}, len(prompts))
+		for _, p := range prompts {
+			definition, _ := p.(map[string]interface{})
+			name, _ := definition["name"].(string)
+			answer, err := b.ask(definition)
+			if err != nil {
+				return nil, false, err
+			}
+			answers[name] = answer
+		}
+		return answers, true, nil
+	case "autocomplete/query":
+		name, _ := body["name"].(string)
+		query := ""
+		if s, ok := b.searches[name]; ok {
+			query = s.query
+		}
+		return map[string]interface{}{"query": query}, false, nil
+	case "autocomplete/choices":
+		name, _ := body["name"].(string)
+		query, _ := body["query"].(string)
+		if s, ok := b.searches[name]; ok && s.query == query {
+			choices, _ := body["choices"].([]interface{})
+			s.choices = append(s.choices, choices...)
+			s.next()
+		}
+		return nil, false, nil
+	case "print":
+		text, _ := body["text"].(string)
+		b.prints = append(b.prints, text)
+		return nil, false, nil
+	case "start-spinner", "stop-spinner", "progress-bar/start", "progress-bar/advance", "progress-bar/stop":
+		return nil, false, nil
+	case "config/get":
+		if value, ok := b.config[key]; ok {
+			return value, false, nil
+		}
+		return nil, false, nil
+	case "config/get-all":
+		return b.getConfig(), false, nil
+	case "config/set":
+		value, _ := body["value"].(string)
+		b.config[key] = value
+		return nil, false, nil
+	case "config/delete":
+		_, ok := b.config[key]
+		delete(b.config, key)
+		return ok, false, nil
+	case "state/get":
+		return b.state[key], false, nil
+	case "state/get-all":
+		return b.getState(), false, nil
+	case "state/set":
+		b.state[key] = body["value"]
+		return nil, false, nil
+	case "secret/get":
+		if value, ok := b.secrets[key]; ok {
+			return map[string]interface{}{key: value}, true, nil
+		}
+		answer, err := b.answer(key)
+		if err != nil {
+			return nil, false, &httpError{http.StatusNotFound, fmt.Sprintf("secret %s not found and no answer queued", key)}
+		}
+		value, _ := answer.(string)
+		b.secrets[key] = value
+		return map[string]interface{}{key: value}, true, nil
+	case "secret/set":
+		value, _ := body["value"].(string)
+		b.secrets[key] = value
+		return map[string]interface{}{"key": key}, true, nil
+	case "track":
+		b.tracked = append(b.tracked, newEvent(body))
+		return nil, false, nil
+	case "events":
+		return b.events, false, nil
+	case "user":
+		return b.user, false, nil
+	case "team":
+		return b.team, false, nil
+	}
+	return nil, false, &httpError{http.StatusNotFound, fmt.Sprintf("unknown endpoint %s", endpoint)}
+}
+
+// ask records a prompt definition and returns its queued answer; b.mu must
+// be held
+func (b *backend) ask(definition map[string]interface{}) (interface{}, error) {
+	b.prompts = append(b.prompts, definition)
+	name, _ := definition["name"].(string)
+	answer, err := b.answer(name)
+	if err != nil {
+		return nil, err
+	}
+	return labeledAnswer(definition, answer), nil
+}
+
+// labeledAnswer replaces the labels in answer with the values of the
+// labeled choices of definition, if it has any
+func labeledAnswer(definition map[string]interface{}, answer interface{}) interface{} {
+	values := make(map[string]string)
+	choices, _ := definition["choices"].([]interface{})
+	for _, c := range choices {
+		if choice, ok := c.(map[string]interface{}); ok {
+			label, _ := choice["label"].(string)
+			values[label], _ = choice["value"].(string)
+		}
+	}
+	if len(values) == 0 {
+		return answer
+	}
+
+	value := func(label string) string {
+		if v, ok := values[label]; ok {
+			return v
+		}
+		return label
+	}
+	switch answer := answer.(type) {
+	case string:
+		return value(answer)
+	case []string:
+		translated := make([]string, len(answer))
+		for i, label := range answer {
+			translated[i] = value(label)
+		}
+		return translated
+	}
+	return answer
+}
+
+// search is a dynamic autocomplete prompt being asked
+type search struct {
+	name string
+	// queries are the queries still to be typed, after query
+	queries []string
+	query   string
+	// choices are all the choices served, as sent by the SDK
+	choices []interface{}
+	done    chan struct{}
+}
+
+// next types the next query, or closes done if there are none left
+func (s *search) next() {
+	select {
+	case <-s.done:
+		return
+	default:
+	}
+	if len(s.queries) == 0 {
+		s.query = ""
+		close(s.done)
+		return
+	}
+	s.query, s.queries = s.queries[0], s.queries[1:]
+}
+
+// startSearch records a dynamic autocomplete prompt and starts typing the
+// queries queued for it; b.mu must be held
+func (b *backend) startSearch(definition map[string]interface{}) *search {
+	b.prompts = append(b.prompts, definition)
+	name, _ := definition["name"].(string)
+	choices, _ := definition["choices"].([]interface{})
+	s := &search{name: name, queries: b.queries[name], choices: choices, done: make(chan struct{})}
+	delete(b.queries, name)
+	b.searches[name] = s
+	s.next()
+	return s
+}
+
+// awaitSearch waits until every query typed into s has been served, then
+// returns its queued answer, given by the label of any choice served
+func (b *backend) awaitSearch(ctx context.Context, s *search) (interface{}, error) {
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.searches, s.name)
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("prompt %s closed while waiting for choices for %q: %w", s.name, s.query, ctx.Err())
+	}
+	answer, err := b.answer(s.name)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{s.name: labeledAnswer(map[string]interface{}{"choices": s.choices}, answer)}, nil
+}
+
+// answer dequeues the next answer for name; b.mu must be held
+func (b *backend) answer(name string) (interface{}, error) {
+	answers := b.answers[name]
+	if len(answers) == 0 {
+		return nil, &httpError{http.StatusBadRequest, fmt.Sprintf("no answer queued for prompt %s", name)}
+	}
+	b.answers[name] = answers[1:]
+	return answers[0], nil
+}
+
+func (b *backend) setCapabilities(caps ctoai.Capabilities) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.caps = caps
+}
+
+func (b *backend) queueAnswers(name string, answers []interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.answers[name] = append(b.answers[name], answers...)
+}
+
+func (b *backend) queueQueries(name string, queries []string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.queries[name] = append(b.queries[name], queries...)
+}
+
+func (b *backend) failNext(endpoint string, status int, body string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures[endpoint] = append(b.failures[endpoint], failure{status: status, body: body})
+}
+
+func (b *backend) setConfig(key, value string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.config[key] = value
+}
+
+func (b *backend) setState(key string, value interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state[key] = value
+}
+
+func (b *backend) setSecret(key, value string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.secrets[key] = value
+}
+
+func (b *backend) setEvents(events []map[string]interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.events = events
+}
+
+func (b *backend) setUser(user ctoai.UserInfo) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.user = user
+}
+
+func (b *backend) setTeam(team ctoai.TeamInfo) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.team = team
+}
+
+// getConfig copies the config store; b.mu must be held
+func (b *backend) getConfig() map[string]string {
+	config := make(map[string]string, len(b.config))
+	for k, v := range b.config {
+		config[k] = v
+	}
+	return config
+}
+
+// getState copies the state store; b.mu must be held
+func (b *backend) getState() map[string]interface{} {
+	state := make(map[string]interface{}, len(b.state))
+	for k, v := range b.state {
+		state[k] = v
+	}
+	return state
+}
+
+func (b *backend) configSnapshot() map[string]string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.getConfig()
+}
+
+func (b *backend) stateSnapshot() map[string]interface{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.getState()
+}
+
+func (b *backend) secretsSnapshot() map[string]string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	secrets := make(map[string]string, len(b.secrets))
+	for k, v := range b.secrets {
+		secrets[k] = v
+	}
+	return secrets
+}
+
+func (b *backend) requestsSnapshot() []Request {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]Request(nil), b.requests...)
+}
+
+func (b *backend) promptsSnapshot() []map[string]interface{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]map[string]interface{}(nil), b.prompts...)
+}
+
+func (b *backend) prompt(name string) map[string]interface{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i := len(b.prompts) - 1; i >= 0; i-- {
+		if b.prompts[i]["name"] == name {
+			return b.prompts[i]
+		}
+	}
+	return nil
+}
+
+func (b *backend) printsSnapshot() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.prints...)
+}
+
+func (b *backend) trackedSnapshot() []Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]Event(nil), b.tracked...)
+}
+
+func (b *backend) pending() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var names []string
+	for name, answers := range b.answers {
+		if len(answers) > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// roundTrip converts value to its JSON form and decodes it into target
+func roundTrip(value, target interface{}) error {
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("Error marshalling JSON: %w", err)
+	}
+	return json.Unmarshal(bytes, target)
+}
+
+func newEvent(body map[string]interface{}) Event {
+	event := Event{Metadata: make(map[string]interface{})}
+	for k, v := range body {
+		switch k {
+		case "tags":
+			tags, _ := v.([]interface{})
+			for _, tag := range tags {
+				if s, ok := tag.(string); ok {
+					event.Tags = append(event.Tags, s)
+				}
+			}
+		case "event":
+			event.Event, _ = v.(string)
+		default:
+			event.Metadata[k] = v
+		}
+	}
+	return event
+}
@@ -0,0 +1,286 @@
+// Package ctoaitest provides a fake SDK daemon for testing ops.
+//
+// The fake daemon serves every endpoint used by the SDK over HTTP. Tests
+// queue answers to prompts by name, seed config, state and secrets, run
+// the op against a client connected to the fake, then assert on what the
+// op printed, tracked and asked.
+//
+// Code that depends only on the ctoai.Prompter, ctoai.UX or ctoai.Platform
+// interfaces can instead be tested with the in-memory fakes returned by
+// NewPrompter, NewUX and NewPlatform.
+//
+// Example:
+//
+//  d := ctoaitest.New(t)
+//  defer d.Close()
+//
+//  d.Answer("region", "eu")
+//  d.SeedSecret("token", "hunter2")
+//
+//  err := deploy(d.Client())
+//
+//  if prints := d.Prints(); prints[len(prints)-1] != "Deployed to eu" {
+//      t.Errorf("unexpected output %v", prints)
+//  }
+package ctoaitest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	ctoai "github.com/cto-ai/sdk-go/v2"
+)
+
+// Request is a request received by the fake daemon
+type Request struct {
+	Endpoint string
+	Method   string
+	Body     map[string]interface{}
+}
+
+// Event is an event sent with Sdk.Track or Sdk.Start
+type Event struct {
+	Tags     []string
+	Event    string
+	Metadata map[string]interface{}
+}
+
+// Daemon is a fake SDK daemon. It is safe for concurrent use.
+type Daemon struct {
+	t        testing.TB
+	server   *httptest.Server
+	replyDir string
+	replies  int64
+	backend  *backend
+}
+
+// New starts a fake daemon. Unexpected requests, such as a prompt with no
+// queued answer, fail the request and are reported with t.Errorf.
+//
+// The daemon must be stopped with Close.
+func New(t testing.TB) *Daemon {
+	replyDir, err := ioutil.TempDir("", "ctoaitest")
+	if err != nil {
+		t.Fatalf("Error creating reply directory: %v", err)
+	}
+
+	d := &Daemon{
+		t:        t,
+		replyDir: replyDir,
+		backend:  newBackend(),
+	}
+	d.server = httptest.NewServer(http.HandlerFunc(d.serveHTTP))
+	return d
+}
+
+// Close stops the daemon and removes its reply files
+func (d *Daemon) Close() {
+	d.server.Close()
+	os.RemoveAll(d.replyDir)
+}
+
+// Port returns the port the daemon listens on, as SDK_SPEAK_PORT
+func (d *Daemon) Port() int {
+	return d.server.Listener.Addr().(*net.TCPAddr).Port
+}
+
+// ClientOptions returns the options connecting a client to the daemon
+func (d *Daemon) ClientOptions() []ctoai.ClientOption {
+	return []ctoai.ClientOption{
+		ctoai.OptClientPort(d.Port()),
+		ctoai.OptClientReplyDir(d.replyDir),
+		ctoai.OptClientOffline(false),
+	}
+}
+
+// Client returns a client connected to the daemon. Further options are
+// applied after the connection options.
+func (d *Daemon) Client(options ...ctoai.ClientOption) ctoai.Client {
+	return ctoai.NewClient(append(d.ClientOptions(), options...)...)
+}
+
+// SetCapabilities sets the capabilities reported by the daemon, e.g. to
+// test an op against an older daemon. By default the daemon supports
+// everything.
+func (d *Daemon) SetCapabilities(caps ctoai.Capabilities) {
+	d.backend.setCapabilities(caps)
+}
+
+// Answer queues answers to the prompt called name, which are used in
+// order. The answers are sent as JSON, e.g. a string for an input, list or
+// duration prompt, a []string for a checkbox prompt or a time.Time for a
+// datetime prompt. Labeled choices, as asked by ListChoices and
+// CheckboxChoices, are answered by their labels.
+//
+// A secret missing from the store is also answered from the queue of the
+// prompt named after its key.
+func (d *Daemon) Answer(name string, answers ...interface{}) {
+	d.backend.queueAnswers(name, answers)
+}
+
+// Type queues queries to be typed, in order, into the next dynamic
+// autocomplete prompt called name, as asked by Prompt.Autocomplete. The
+// prompt is answered from the queue of Answer once the op has served the
+// choices for every query, so its answer may be the label of any choice
+// served.
+func (d *Daemon) Type(name string, queries ...string) {
+	d.backend.queueQueries(name, queries)
+}
+
+// FailNext makes the next request to endpoint respond with status and
+// body instead of being handled, e.g. to test how an op handles the user
+// cancelling a prompt.
+func (d *Daemon) FailNext(endpoint string, status int, body string) {
+	d.backend.failNext(endpoint, status, body)
+}
+
+// SeedConfig seeds a value in the config store
+func (d *Daemon) SeedConfig(key, value string) {
+	d.backend.setConfig(key, value)
+}
+
+// SeedState seeds a value in the state store
+func (d *Daemon) SeedState(key string, value interface{}) {
+	d.backend.setState(key, value)
+}
+
+// SeedSecret seeds a value in the secret store
+func (d *Daemon) SeedSecret(key, value string) {
+	d.backend.setSecret(key, value)
+}
+
+// SetEvents sets the events returned by Sdk.Events
+func (d *Daemon) SetEvents(events []map[string]interface{}) {
+	d.backend.setEvents(events)
+}
+
+// SetUser sets the user returned by Sdk.User
+func (d *Daemon) SetUser(user ctoai.UserInfo) {
+	d.backend.setUser(user)
+}
+
+// SetTeam sets the team returned by Sdk.Team
+func (d *Daemon) SetTeam(team ctoai.TeamInfo) {
+	d.backend.setTeam(team)
+}
+
+// Config returns a copy of the config store
+func (d *Daemon) Config() map[string]string {
+	return d.backend.configSnapshot()
+}
+
+// State returns a copy of the state store
+func (d *Daemon) State() map[string]interface{} {
+	return d.backend.stateSnapshot()
+}
+
+// Secrets returns a copy of the secret store
+func (d *Daemon) Secrets() map[string]string {
+	return d.backend.secretsSnapshot()
+}
+
+// Requests returns every request received, in order
+func (d *Daemon) Requests() []Request {
+	return d.backend.requestsSnapshot()
+}
+
+// Prompts returns the definitions of the prompts asked, in order. Prompts
+// asked together with Prompt.Form are listed individually.
+func (d *Daemon) Prompts() []map[string]interface{} {
+	return d.backend.promptsSnapshot()
+}
+
+// Prompt returns the definition of the last prompt called name, or nil if
+// it was not asked.
+func (d *Daemon) Prompt(name string) map[string]interface{} {
+	return d.backend.prompt(name)
+}
+
+// Prints returns the text printed with Ux.Print, in order
+func (d *Daemon) Prints() []string {
+	return d.backend.printsSnapshot()
+}
+
+// Tracked returns the events sent with Sdk.Track and Sdk.Start, in order
+func (d *Daemon) Tracked() []Event {
+	return d.backend.trackedSnapshot()
+}
+
+// Pending returns the names of prompts with queued answers that were never
+// asked, e.g. to check that an op asked everything a test expected.
+func (d *Daemon) Pending() []string {
+	return d.backend.pending()
+}
+
+func (d *Daemon) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	endpoint := strings.TrimPrefix(r.URL.Path, "/")
+	if endpoint == "" {
+		// Ping
+		return
+	}
+
+	body := make(map[string]interface{})
+	if r.Method == "POST" {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			d.fail(w, &httpError{http.StatusBadRequest, fmt.Sprintf("invalid body for %s: %v", endpoint, err)})
+			return
+		}
+	}
+
+	response, async, failed, err := d.backend.serve(r.Context(), endpoint, r.Method, body)
+	if failed != nil {
+		w.WriteHeader(failed.status)
+		fmt.Fprint(w, failed.body)
+		return
+	}
+	if err != nil {
+		d.fail(w, err)
+		return
+	}
+
+	if async {
+		filename, err := d.writeReply(response)
+		if err != nil {
+			d.fail(w, err)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"replyFilename": filename})
+		return
+	}
+	if response != nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{"value": response})
+	}
+}
+
+func (d *Daemon) fail(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if httpErr, ok := err.(*httpError); ok {
+		status = httpErr.status
+	}
+	d.t.Errorf("ctoaitest: %v", err)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"message": err.Error()})
+}
+
+// writeReply writes an async response to a new reply file
+func (d *Daemon) writeReply(response interface{}) (string, error) {
+	bytes, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	filename := filepath.Join(d.replyDir, fmt.Sprintf("reply-%d", atomic.AddInt64(&d.replies, 1)))
+	if err := ioutil.WriteFile(filename, bytes, 0600); err != nil {
+		return "", err
+	}
+	return filename, nil
+}
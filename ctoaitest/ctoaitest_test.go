@@ -0,0 +1,227 @@
+package ctoaitest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	ctoai "github.com/cto-ai/sdk-go/v2"
+)
+
+func Test_Daemon_Prompts(t *testing.T) {
+	d := New(t)
+	defer d.Close()
+	c := d.Client()
+
+	d.Answer("region", "eu")
+	d.Answer("replicas", 3)
+	d.Answer("features", []string{"a", "c"})
+	d.Answer("unused", "x")
+
+	region, err := c.Prompt.List("region", "Which region?", []string{"us", "eu"}, ctoai.OptListFlag("r"))
+	if err != nil || region != "eu" {
+		t.Errorf("Error unexpected list answer: %v, %v", region, err)
+	}
+	replicas, err := c.Prompt.Number("replicas", "How many?")
+	if err != nil || replicas != 3 {
+		t.Errorf("Error unexpected number answer: %v, %v", replicas, err)
+	}
+	features, err := c.Prompt.Checkbox("features", "Features?", []string{"a", "b", "c"})
+	if err != nil || !reflect.DeepEqual(features, []string{"a", "c"}) {
+		t.Errorf("Error unexpected checkbox answer: %v, %v", features, err)
+	}
+
+	definition := d.Prompt("region")
+	if definition["type"] != "list" || definition["flag"] != "r" || definition["message"] != "Which region?" {
+		t.Errorf("Error unexpected prompt definition: %v", definition)
+	}
+	if len(d.Prompts()) != 3 {
+		t.Errorf("Error expected 3 prompts, got %v", d.Prompts())
+	}
+	if pending := d.Pending(); !reflect.DeepEqual(pending, []string{"unused"}) {
+		t.Errorf("Error unexpected pending answers: %v", pending)
+	}
+}
+
+func Test_Daemon_Form(t *testing.T) {
+	d := New(t)
+	defer d.Close()
+	c := d.Client()
+
+	d.Answer("name", "api")
+	d.Answer("public", true)
+
+	answers, err := c.Prompt.Form().
+		Input("name", "Service name?").
+		Confirm("public", "Public?").
+		Run()
+	if err != nil {
+		t.Fatalf("Error running form: %v", err)
+	}
+	if name := answers.String("name"); name != "api" {
+		t.Errorf("Error unexpected name: %v", name)
+	}
+	if public := answers.Bool("public"); !public {
+		t.Errorf("Error unexpected public: %v", public)
+	}
+}
+
+func Test_Daemon_LabeledChoices(t *testing.T) {
+	d := New(t)
+	defer d.Close()
+	c := d.Client()
+
+	choices := []ctoai.Choice{{Label: "prod", Value: "c-1"}, {Label: "staging", Value: "c-2"}}
+	d.Answer("cluster", "staging")
+	d.Answer("clusters", []string{"prod", "staging"})
+
+	cluster, err := c.Prompt.ListChoices("cluster", "Which cluster?", choices)
+	if err != nil || cluster != "c-2" {
+		t.Errorf("Error unexpected list answer: %v, %v", cluster, err)
+	}
+	clusters, err := c.Prompt.CheckboxChoices("clusters", "Which clusters?", choices)
+	if err != nil || !reflect.DeepEqual(clusters, []interface{}{"c-1", "c-2"}) {
+		t.Errorf("Error unexpected checkbox answer: %v, %v", clusters, err)
+	}
+}
+
+func Test_Daemon_Autocomplete(t *testing.T) {
+	d := New(t)
+	defer d.Close()
+	c := d.Client()
+
+	repos := []string{"cto-ai/ops", "cto-ai/sdk-go", "cto-ai/sdk-js", "golang/go"}
+	source := func(ctx context.Context, query string) ([]ctoai.Choice, error) {
+		if query == "?" {
+			return nil, errors.New("invalid query")
+		}
+		var choices []ctoai.Choice
+		for i, repo := range repos {
+			if strings.Contains(repo, query) {
+				choices = append(choices, ctoai.Choice{Label: repo, Value: i})
+			}
+		}
+		return choices, nil
+	}
+
+	d.Type("repo", "?", "sdk")
+	d.Answer("repo", "cto-ai/sdk-js")
+
+	repo, err := c.Prompt.Autocomplete("repo", "Which repository?", source, ctoai.OptAutocompleteLimit(3), ctoai.OptAutocompleteDebounce(time.Millisecond))
+	if err != nil || repo != 2 {
+		t.Errorf("Error unexpected autocomplete answer: %v, %v", repo, err)
+	}
+
+	if choices, _ := d.Prompt("repo")["choices"].([]interface{}); len(choices) != 3 {
+		t.Errorf("Error expected 3 initial choices, got %v", choices)
+	}
+	var served []map[string]interface{}
+	for _, request := range d.Requests() {
+		if request.Endpoint == "autocomplete/choices" {
+			served = append(served, request.Body)
+		}
+	}
+	if len(served) != 2 || served[0]["query"] != "?" || served[0]["error"] != "invalid query" || served[1]["query"] != "sdk" {
+		t.Fatalf("Error unexpected choices served: %v", served)
+	}
+	if choices, _ := served[1]["choices"].([]interface{}); len(choices) != 2 {
+		t.Errorf("Error expected 2 choices for sdk, got %v", choices)
+	}
+}
+
+func Test_Daemon_Stores(t *testing.T) {
+	d := New(t)
+	defer d.Close()
+	c := d.Client()
+
+	d.SeedConfig("env", "prod")
+	d.SeedState("count", 1)
+	d.SeedSecret("token", "hunter2")
+	d.Answer("api-key", "abc")
+
+	if env, err := c.Sdk.GetConfig("env"); err != nil || env != "prod" {
+		t.Errorf("Error unexpected config: %v, %v", env, err)
+	}
+	if err := c.Sdk.SetConfig("region", "eu"); err != nil {
+		t.Errorf("Error setting config: %v", err)
+	}
+	if deleted, err := c.Sdk.DeleteConfig("env"); err != nil || !deleted {
+		t.Errorf("Error deleting config: %v, %v", deleted, err)
+	}
+	if config := d.Config(); !reflect.DeepEqual(config, map[string]string{"region": "eu"}) {
+		t.Errorf("Error unexpected config store: %v", config)
+	}
+
+	if count, err := c.Sdk.GetState("count"); err != nil || count != float64(1) {
+		t.Errorf("Error unexpected state: %v, %v", count, err)
+	}
+
+	if token, err := c.Sdk.GetSecret("token"); err != nil || token != "hunter2" {
+		t.Errorf("Error unexpected secret: %v, %v", token, err)
+	}
+	if key, err := c.Sdk.GetSecret("api-key"); err != nil || key != "abc" {
+		t.Errorf("Error unexpected prompted secret: %v, %v", key, err)
+	}
+	if _, err := c.Sdk.SetSecret("password", "s3cret"); err != nil {
+		t.Errorf("Error setting secret: %v", err)
+	}
+	if secrets := d.Secrets(); secrets["api-key"] != "abc" || secrets["password"] != "s3cret" {
+		t.Errorf("Error unexpected secret store: %v", secrets)
+	}
+}
+
+func Test_Daemon_UxAndTracking(t *testing.T) {
+	d := New(t)
+	defer d.Close()
+	c := d.Client()
+
+	d.SetTeam(ctoai.TeamInfo{ID: "t1", Name: "platform"})
+
+	c.Ux.SpinnerStart("Deploying")
+	c.Ux.Print("Deployed")
+	c.Ux.SpinnerStop("Done")
+	c.Sdk.Track([]string{"deploy"}, "deployed", map[string]interface{}{"region": "eu"})
+
+	if prints := d.Prints(); !reflect.DeepEqual(prints, []string{"Deployed"}) {
+		t.Errorf("Error unexpected prints: %v", prints)
+	}
+	expected := []Event{{Tags: []string{"deploy"}, Event: "deployed", Metadata: map[string]interface{}{"region": "eu"}}}
+	if tracked := d.Tracked(); !reflect.DeepEqual(tracked, expected) {
+		t.Errorf("Error unexpected tracked events: %v", tracked)
+	}
+	if requests := d.Requests(); len(requests) != 4 || requests[0].Endpoint != "start-spinner" {
+		t.Errorf("Error unexpected requests: %v", requests)
+	}
+
+	if team, err := c.Sdk.Team(); err != nil || team.Name != "platform" {
+		t.Errorf("Error unexpected team: %v, %v", team, err)
+	}
+	if user, err := c.Sdk.User(); err != nil || user.Username != "test" {
+		t.Errorf("Error unexpected user: %v, %v", user, err)
+	}
+	if events, err := c.Sdk.Events("", ""); err != nil || len(events) != 0 {
+		t.Errorf("Error unexpected events: %v, %v", events, err)
+	}
+}
+
+func Test_Daemon_FailNext(t *testing.T) {
+	d := New(t)
+	defer d.Close()
+	c := d.Client()
+
+	d.FailNext("prompt", 499, `{"message": "cancelled", "code": "user_cancelled"}`)
+
+	_, err := c.Prompt.Input("name", "Name?")
+	if !ctoai.IsUserCancelled(err) {
+		t.Errorf("Error expected user cancelled error, got: %v", err)
+	}
+
+	d.FailNext("config/get", http.StatusNotFound, `{"message": "missing"}`)
+	if _, err := c.Sdk.GetConfig("env"); !ctoai.IsNotFound(err) {
+		t.Errorf("Error expected not found error, got: %v", err)
+	}
+}
@@ -0,0 +1,171 @@
+package ctoaitest
+
+import (
+	ctoai "github.com/cto-ai/sdk-go/v2"
+)
+
+// Prompter is an in-memory ctoai.Prompter. It builds and decodes prompts
+// exactly like ctoai.Prompt, but answers them from queued answers instead
+// of asking a daemon.
+//
+// Example:
+//
+//  p := ctoaitest.NewPrompter()
+//  p.Answer("region", "eu")
+//
+//  region, err := chooseRegion(p) // chooseRegion(p ctoai.Prompter)
+type Prompter struct {
+	*ctoai.Prompt
+	backend *backend
+}
+
+// NewPrompter creates a Prompter with no queued answers
+func NewPrompter() *Prompter {
+	b := newBackend()
+	return &Prompter{Prompt: ctoai.NewPrompt(ctoai.OptClientHandler(b.handler)), backend: b}
+}
+
+// Answer queues answers to the prompt called name; see Daemon.Answer. A
+// prompt with no queued answer fails with a *ctoai.DaemonError.
+func (p *Prompter) Answer(name string, answers ...interface{}) {
+	p.backend.queueAnswers(name, answers)
+}
+
+// Type queues queries to be typed into the next dynamic autocomplete prompt
+// called name; see Daemon.Type.
+func (p *Prompter) Type(name string, queries ...string) {
+	p.backend.queueQueries(name, queries)
+}
+
+// SetCapabilities sets the daemon capabilities the prompts are adapted to;
+// see Daemon.SetCapabilities.
+func (p *Prompter) SetCapabilities(caps ctoai.Capabilities) {
+	p.backend.setCapabilities(caps)
+}
+
+// FailNext makes the next prompt fail with status and body, e.g. 499 and
+// {"code": "user_cancelled"} to simulate the user cancelling it.
+func (p *Prompter) FailNext(status int, body string) {
+	p.backend.failNext("prompt", status, body)
+}
+
+// Prompts returns the definitions of the prompts asked, in order
+func (p *Prompter) Prompts() []map[string]interface{} {
+	return p.backend.promptsSnapshot()
+}
+
+// Definition returns the definition of the last prompt called name, or nil
+// if it was not asked.
+func (p *Prompter) Definition(name string) map[string]interface{} {
+	return p.backend.prompt(name)
+}
+
+// Pending returns the names of prompts with queued answers that were never
+// asked.
+func (p *Prompter) Pending() []string {
+	return p.backend.pending()
+}
+
+// Prints returns the validation messages printed for rejected answers, in
+// order
+func (p *Prompter) Prints() []string {
+	return p.backend.printsSnapshot()
+}
+
+// UX is an in-memory ctoai.UX that records what is printed
+type UX struct {
+	*ctoai.Ux
+	backend *backend
+}
+
+// NewUX creates a UX with nothing printed
+func NewUX() *UX {
+	b := newBackend()
+	return &UX{Ux: ctoai.NewUx(ctoai.OptClientHandler(b.handler)), backend: b}
+}
+
+// Prints returns the text printed, in order
+func (u *UX) Prints() []string {
+	return u.backend.printsSnapshot()
+}
+
+// Requests returns every call made, including spinners and progress bars,
+// as the request that would have been sent to the daemon
+func (u *UX) Requests() []Request {
+	return u.backend.requestsSnapshot()
+}
+
+// Platform is an in-memory ctoai.Platform with its own config, state and
+// secret stores
+type Platform struct {
+	*ctoai.Sdk
+	backend *backend
+}
+
+// NewPlatform creates a Platform with empty stores
+func NewPlatform() *Platform {
+	b := newBackend()
+	return &Platform{Sdk: ctoai.NewSdk(ctoai.OptClientHandler(b.handler)), backend: b}
+}
+
+// Answer queues values for secrets missing from the secret store, by key,
+// as if the user had been prompted for them.
+func (s *Platform) Answer(key string, values ...interface{}) {
+	s.backend.queueAnswers(key, values)
+}
+
+// SeedConfig seeds a value in the config store
+func (s *Platform) SeedConfig(key, value string) {
+	s.backend.setConfig(key, value)
+}
+
+// SeedState seeds a value in the state store
+func (s *Platform) SeedState(key string, value interface{}) {
+	s.backend.setState(key, value)
+}
+
+// SeedSecret seeds a value in the secret store
+func (s *Platform) SeedSecret(key, value string) {
+	s.backend.setSecret(key, value)
+}
+
+// SetEvents sets the events returned by Events
+func (s *Platform) SetEvents(events []map[string]interface{}) {
+	s.backend.setEvents(events)
+}
+
+// SetUser sets the user returned by User
+func (s *Platform) SetUser(user ctoai.UserInfo) {
+	s.backend.setUser(user)
+}
+
+// SetTeam sets the team returned by Team
+func (s *Platform) SetTeam(team ctoai.TeamInfo) {
+	s.backend.setTeam(team)
+}
+
+// Config returns a copy of the config store
+func (s *Platform) Config() map[string]string {
+	return s.backend.configSnapshot()
+}
+
+// State returns a copy of the state store
+func (s *Platform) State() map[string]interface{} {
+	return s.backend.stateSnapshot()
+}
+
+// Secrets returns a copy of the secret store
+func (s *Platform) Secrets() map[string]string {
+	return s.backend.secretsSnapshot()
+}
+
+// Tracked returns the events sent with Track and Start, in order
+func (s *Platform) Tracked() []Event {
+	return s.backend.trackedSnapshot()
+}
+
+var (
+	_ ctoai.Prompter = (*Prompter)(nil)
+	_ ctoai.UX       = (*UX)(nil)
+	_ ctoai.Platform = (*Platform)(nil)
+)
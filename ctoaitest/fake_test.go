@@ -0,0 +1,162 @@
+package ctoaitest
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	ctoai "github.com/cto-ai/sdk-go/v2"
+)
+
+func Test_Prompter(t *testing.T) {
+	p := NewPrompter()
+	when := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	p.Answer("name", "api")
+	p.Answer("when", when)
+	p.Answer("replicas", 2)
+	p.Answer("canary", true)
+
+	var prompter ctoai.Prompter = p
+	if name, err := prompter.Input("name", "Name?", ctoai.OptInputDefault("web")); err != nil || name != "api" {
+		t.Errorf("Error unexpected input answer: %v, %v", name, err)
+	}
+	if answer, err := prompter.Datetime("when", "When?"); err != nil || !answer.Equal(when) {
+		t.Errorf("Error unexpected datetime answer: %v, %v", answer, err)
+	}
+	answers, err := prompter.Form().Number("replicas", "How many?").Confirm("canary", "Canary?").Run()
+	if err != nil || answers.Int("replicas") != 2 || !answers.Bool("canary") {
+		t.Errorf("Error unexpected form answers: %v, %v", answers, err)
+	}
+
+	if definition := p.Definition("name"); definition["default"] != "web" {
+		t.Errorf("Error unexpected definition: %v", definition)
+	}
+
+	_, err = prompter.Confirm("missing", "Missing?")
+	var daemonErr *ctoai.DaemonError
+	if !errors.As(err, &daemonErr) {
+		t.Errorf("Error expected DaemonError for unanswered prompt, got: %v", err)
+	}
+
+	p.FailNext(499, `{"message": "cancelled", "code": "user_cancelled"}`)
+	if _, err := prompter.Input("name", "Name?"); !ctoai.IsUserCancelled(err) {
+		t.Errorf("Error expected user cancelled error, got: %v", err)
+	}
+
+	p.Answer("count", 3, 4)
+	even := ctoai.OptNumberValidate(func(n int) error {
+		if n%2 != 0 {
+			return errors.New("must be even")
+		}
+		return nil
+	})
+	if count, err := prompter.Number("count", "Count?", even); err != nil || count != 4 {
+		t.Errorf("Error unexpected validated answer: %v, %v", count, err)
+	}
+	if prints := p.Prints(); !reflect.DeepEqual(prints, []string{"must be even"}) {
+		t.Errorf("Error unexpected validation prints: %v", prints)
+	}
+
+	p.Type("image", "alp")
+	p.Answer("image", "alpine")
+	images := func(ctx context.Context, query string) ([]ctoai.Choice, error) {
+		return []ctoai.Choice{{Label: query + "ine"}}, nil
+	}
+	if image, err := prompter.Autocomplete("image", "Image?", images, ctoai.OptAutocompleteDebounce(0)); err != nil || image != "alpine" {
+		t.Errorf("Error unexpected autocomplete answer: %v, %v", image, err)
+	}
+}
+
+func Test_UX(t *testing.T) {
+	u := NewUX()
+
+	var ux ctoai.UX = u
+	ux.ProgressBarStart(3, 0, "Working")
+	ux.Print("halfway")
+	ux.ProgressBarStop("Done")
+
+	if prints := u.Prints(); !reflect.DeepEqual(prints, []string{"halfway"}) {
+		t.Errorf("Error unexpected prints: %v", prints)
+	}
+	if requests := u.Requests(); len(requests) != 3 || requests[2].Body["text"] != "Done" {
+		t.Errorf("Error unexpected requests: %v", requests)
+	}
+}
+
+func Test_UX_Handles(t *testing.T) {
+	u := NewUX()
+
+	var ux ctoai.UX = u
+	spinner, err := ux.StartSpinner("Deploying")
+	if err != nil {
+		t.Fatalf("Error starting spinner: %v", err)
+	}
+	bar, err := ux.StartProgressBar(2, 0, "Uploading")
+	if err != nil {
+		t.Fatalf("Error starting progress bar: %v", err)
+	}
+	bar.Advance(2)
+	bar.Stop("Uploaded")
+	spinner.Stop("Deployed")
+
+	var endpoints []string
+	for _, request := range u.Requests() {
+		endpoints = append(endpoints, request.Endpoint)
+	}
+	expected := []string{"start-spinner", "progress-bar/start", "progress-bar/advance", "progress-bar/stop", "stop-spinner"}
+	if !reflect.DeepEqual(endpoints, expected) {
+		t.Errorf("Error unexpected requests: %v", endpoints)
+	}
+}
+
+func Test_Platform(t *testing.T) {
+	s := NewPlatform()
+	s.SeedConfig("env", "prod")
+	s.SetUser(ctoai.UserInfo{ID: "u1", Username: "alice"})
+
+	var platform ctoai.Platform = s
+	if !platform.DaemonAvailable() {
+		t.Errorf("Error fake platform should report the daemon available")
+	}
+	if env, err := platform.GetConfig("env"); err != nil || env != "prod" {
+		t.Errorf("Error unexpected config: %v, %v", env, err)
+	}
+	if err := platform.SetState("step", "deploy"); err != nil {
+		t.Errorf("Error setting state: %v", err)
+	}
+	if all, err := platform.GetAllState(); err != nil || all["step"] != "deploy" {
+		t.Errorf("Error unexpected state: %v, %v", all, err)
+	}
+	if user, err := platform.User(); err != nil || user.Username != "alice" {
+		t.Errorf("Error unexpected user: %v, %v", user, err)
+	}
+	platform.Track([]string{"test"}, "ran", nil)
+
+	if state := s.State(); state["step"] != "deploy" {
+		t.Errorf("Error unexpected state store: %v", state)
+	}
+	if tracked := s.Tracked(); len(tracked) != 1 || tracked[0].Event != "ran" {
+		t.Errorf("Error unexpected tracked events: %v", tracked)
+	}
+}
+
+func Test_ClientFromFakes(t *testing.T) {
+	p, u, s := NewPrompter(), NewUX(), NewPlatform()
+	c := ctoai.NewClient(ctoai.OptClientPrompter(p), ctoai.OptClientUX(u), ctoai.OptClientPlatform(s), ctoai.OptClientOffline(false))
+
+	p.Answer("name", "api")
+	if name, err := c.Prompt.Input("name", "Name?"); err != nil || name != "api" {
+		t.Errorf("Error unexpected answer: %v, %v", name, err)
+	}
+	c.Ux.Print("hello")
+	if prints := u.Prints(); !reflect.DeepEqual(prints, []string{"hello"}) {
+		t.Errorf("Error unexpected prints: %v", prints)
+	}
+	c.Sdk.SetConfig("k", "v")
+	if config := s.Config(); config["k"] != "v" {
+		t.Errorf("Error unexpected config: %v", config)
+	}
+}
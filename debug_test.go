@@ -0,0 +1,82 @@
+package ctoai
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_Debug_LogsExchange(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"replyFilename": "/tmp/response-mocktest"}`)
+	}))
+	defer ts.Close()
+
+	err := ioutil.WriteFile("/tmp/response-mocktest", []byte(`{"test": 42}`), 0600)
+	if err != nil {
+		t.Fatalf("Error writing reply file: %v", err)
+	}
+
+	var buf bytes.Buffer
+	c := NewClient(OptClientPort(ServerPort(t, ts)), OptClientDebug(&buf))
+	if _, err := c.Prompt.Input("test", "type test"); err == nil {
+		t.Errorf("Error expected non-string answer to fail")
+	}
+
+	log := buf.String()
+	for _, expected := range []string{
+		`POST prompt (async) request {"allowEmpty":false,"message":"type test","name":"test","type":"input"}`,
+		`POST prompt status 200 after`,
+		`response {"test":42}`,
+	} {
+		if !strings.Contains(log, expected) {
+			t.Errorf("Error debug log missing %q:\n%s", expected, log)
+		}
+	}
+}
+
+func Test_Debug_MasksSecrets(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"replyFilename": "/tmp/response-mocktest"}`)
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	c := NewClient(OptClientPort(ServerPort(t, ts)), OptClientDebug(&buf))
+
+	err := ioutil.WriteFile("/tmp/response-mocktest", []byte(`{"test": "hunter2"}`), 0600)
+	if err != nil {
+		t.Fatalf("Error writing reply file: %v", err)
+	}
+	if _, err := c.Prompt.Password("test", "password?"); err != nil {
+		t.Errorf("Error in prompt request: %v", err)
+	}
+
+	err = ioutil.WriteFile("/tmp/response-mocktest", []byte(`{"key": "test"}`), 0600)
+	if err != nil {
+		t.Fatalf("Error writing reply file: %v", err)
+	}
+	if _, err := c.Sdk.SetSecret("test", "hunter2"); err != nil {
+		t.Errorf("Error in secret request: %v", err)
+	}
+
+	err = ioutil.WriteFile("/tmp/response-mocktest", []byte(`{"test": "hunter2"}`), 0600)
+	if err != nil {
+		t.Fatalf("Error writing reply file: %v", err)
+	}
+	if _, err := c.Sdk.GetSecret("test"); err != nil {
+		t.Errorf("Error in secret request: %v", err)
+	}
+
+	log := buf.String()
+	if strings.Contains(log, "hunter2") {
+		t.Errorf("Error secret leaked into debug log:\n%s", log)
+	}
+	if strings.Count(log, "********") != 3 {
+		t.Errorf("Error expected three masked values:\n%s", log)
+	}
+}
@@ -0,0 +1,218 @@
+package ctoai
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cto-ai/sdk-go/v2/internal/daemon"
+)
+
+// dispatcher serializes the UI operations of a Ux, so that operations from
+// concurrent goroutines reach the daemon one at a time and in the order
+// they were made. It also tracks the active spinners and progress bars.
+type dispatcher struct {
+	mu      sync.Mutex
+	busy    bool
+	waiters []chan struct{}
+
+	// The following are only accessed while dispatching
+	spinners    []*spinner
+	bars        []*progressBar
+	queuePrints bool
+	queued      []string
+}
+
+// acquire waits for the turn of the caller. Callers are served in the
+// order they called acquire.
+func (d *dispatcher) acquire(ctx context.Context) error {
+	d.mu.Lock()
+	if !d.busy {
+		d.busy = true
+		d.mu.Unlock()
+		return nil
+	}
+	turn := make(chan struct{})
+	d.waiters = append(d.waiters, turn)
+	d.mu.Unlock()
+
+	select {
+	case <-turn:
+		return nil
+	case <-ctx.Done():
+	}
+
+	d.mu.Lock()
+	for i, waiter := range d.waiters {
+		if waiter == turn {
+			d.waiters = append(d.waiters[:i], d.waiters[i+1:]...)
+			d.mu.Unlock()
+			return ctx.Err()
+		}
+	}
+	d.mu.Unlock()
+
+	// The turn was handed over just as ctx was done; pass it on
+	d.release()
+	return ctx.Err()
+}
+
+// release hands the turn to the next waiting caller
+func (d *dispatcher) release() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.waiters) == 0 {
+		d.busy = false
+		return
+	}
+	next := d.waiters[0]
+	d.waiters = d.waiters[1:]
+	close(next)
+}
+
+// dispatch runs op once all UI operations made before it have finished
+func (d *dispatcher) dispatch(ctx context.Context, op func() error) error {
+	if err := d.acquire(ctx); err != nil {
+		return err
+	}
+	defer d.release()
+	return op()
+}
+
+// active reports whether a spinner or progress bar is shown; it must be
+// called while dispatching
+func (d *dispatcher) active() bool {
+	return len(d.spinners) > 0 || len(d.bars) > 0
+}
+
+// The following Ux methods must be called while dispatching.
+
+// show prints text, or queues it while a spinner or progress bar is shown
+// if prints are queued
+func (u *Ux) show(ctx context.Context, text string) error {
+	if u.ui.queuePrints && u.ui.active() {
+		u.ui.queued = append(u.ui.queued, text)
+		return nil
+	}
+	return u.transport.SimpleRequest(ctx, "print", daemon.PrintBody{Text: text}, "POST")
+}
+
+// flush prints the queued prints once no spinner or progress bar is shown
+func (u *Ux) flush(ctx context.Context) error {
+	for !u.ui.active() && len(u.ui.queued) > 0 {
+		text := u.ui.queued[0]
+		if err := u.transport.SimpleRequest(ctx, "print", daemon.PrintBody{Text: text}, "POST"); err != nil {
+			return err
+		}
+		u.ui.queued = u.ui.queued[1:]
+	}
+	return nil
+}
+
+// pushSpinner shows a new spinner. A legacy spinner, started with
+// SpinnerStart, replaces the current spinner if that is also a legacy one.
+func (u *Ux) pushSpinner(ctx context.Context, text string, legacy bool) (*spinner, error) {
+	if err := u.transport.SimpleRequest(ctx, "start-spinner", daemon.SpinnerStartBody{Text: text}, "POST"); err != nil {
+		return nil, err
+	}
+
+	s := &spinner{ux: u, text: text, legacy: legacy}
+	if n := len(u.ui.spinners); legacy && n > 0 && u.ui.spinners[n-1].legacy {
+		u.ui.spinners[n-1] = s
+	} else {
+		u.ui.spinners = append(u.ui.spinners, s)
+	}
+	return s, nil
+}
+
+// stopSpinner stops s, showing the spinner below it again if s was shown
+func (u *Ux) stopSpinner(ctx context.Context, s *spinner, text string) error {
+	i := indexOf(len(u.ui.spinners), func(i int) bool { return u.ui.spinners[i] == s })
+	if i < 0 {
+		return nil
+	}
+	shown := i == len(u.ui.spinners)-1
+	u.ui.spinners = append(u.ui.spinners[:i], u.ui.spinners[i+1:]...)
+
+	var err error
+	switch {
+	case shown:
+		err = u.transport.SimpleRequest(ctx, "stop-spinner", daemon.SpinnerStopBody{Text: text}, "POST")
+		if n := len(u.ui.spinners); err == nil && n > 0 {
+			err = u.transport.SimpleRequest(ctx, "start-spinner", daemon.SpinnerStartBody{Text: u.ui.spinners[n-1].text}, "POST")
+		}
+	case text != "":
+		err = u.show(ctx, text)
+	}
+	if err != nil {
+		return err
+	}
+	return u.flush(ctx)
+}
+
+// pushBar shows a new progress bar. A legacy bar, started with
+// ProgressBarStart, replaces the current bar if that is also a legacy one.
+func (u *Ux) pushBar(ctx context.Context, length, initial int, text string, legacy bool) (*progressBar, error) {
+	if err := u.transport.SimpleRequest(ctx, "progress-bar/start", daemon.ProgressBarStartBody{Length: length, Initial: initial, Text: text}, "POST"); err != nil {
+		return nil, err
+	}
+
+	b := &progressBar{ux: u, length: length, current: initial, text: text, legacy: legacy}
+	if n := len(u.ui.bars); legacy && n > 0 && u.ui.bars[n-1].legacy {
+		u.ui.bars[n-1] = b
+	} else {
+		u.ui.bars = append(u.ui.bars, b)
+	}
+	return b, nil
+}
+
+// advanceBar records the progress of b, showing it if b is shown
+func (u *Ux) advanceBar(ctx context.Context, b *progressBar, increment int) error {
+	i := indexOf(len(u.ui.bars), func(i int) bool { return u.ui.bars[i] == b })
+	if i < 0 {
+		return nil
+	}
+	b.current += increment
+	if b.current > b.length {
+		b.current = b.length
+	}
+	if i != len(u.ui.bars)-1 {
+		return nil
+	}
+	return u.transport.SimpleRequest(ctx, "progress-bar/advance", daemon.ProgressBarAdvanceBody{Increment: increment}, "POST")
+}
+
+// stopBar stops b, showing the progress bar below it again if b was shown
+func (u *Ux) stopBar(ctx context.Context, b *progressBar, text string) error {
+	i := indexOf(len(u.ui.bars), func(i int) bool { return u.ui.bars[i] == b })
+	if i < 0 {
+		return nil
+	}
+	shown := i == len(u.ui.bars)-1
+	u.ui.bars = append(u.ui.bars[:i], u.ui.bars[i+1:]...)
+
+	var err error
+	switch {
+	case shown:
+		err = u.transport.SimpleRequest(ctx, "progress-bar/stop", daemon.ProgressBarStopBody{Text: text}, "POST")
+		if n := len(u.ui.bars); err == nil && n > 0 {
+			next := u.ui.bars[n-1]
+			err = u.transport.SimpleRequest(ctx, "progress-bar/start", daemon.ProgressBarStartBody{Length: next.length, Initial: next.current, Text: next.text}, "POST")
+		}
+	case text != "":
+		err = u.show(ctx, text)
+	}
+	if err != nil {
+		return err
+	}
+	return u.flush(ctx)
+}
+
+// indexOf returns the first index below n for which match is true, or -1
+func indexOf(n int, match func(int) bool) int {
+	for i := 0; i < n; i++ {
+		if match(i) {
+			return i
+		}
+	}
+	return -1
+}
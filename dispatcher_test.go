@@ -0,0 +1,189 @@
+package ctoai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// uiRecorder records UI requests as "endpoint text" lines
+type uiRecorder struct {
+	mu       sync.Mutex
+	requests []string
+}
+
+func (r *uiRecorder) handle(ctx context.Context, req *DaemonRequest) (interface{}, error) {
+	bytes, _ := json.Marshal(req.Body)
+	var body map[string]interface{}
+	json.Unmarshal(bytes, &body)
+
+	line := req.Endpoint
+	if text, ok := body["text"].(string); ok {
+		line += " " + text
+	}
+	if initial, ok := body["initial"].(float64); ok {
+		line += fmt.Sprintf(" %v/%v", initial, body["length"])
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, line)
+	return nil, nil
+}
+
+func (r *uiRecorder) lines() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.requests...)
+}
+
+func Test_Dispatcher_Serializes(t *testing.T) {
+	var inFlight, overlaps int32
+	c := NewClient(OptClientHandler(func(ctx context.Context, req *DaemonRequest) (interface{}, error) {
+		if atomic.AddInt32(&inFlight, 1) > 1 {
+			atomic.AddInt32(&overlaps, 1)
+		}
+		time.Sleep(time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		return nil, nil
+	}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c.Ux.Print(fmt.Sprintf("worker %d", i))
+		}(i)
+	}
+	wg.Wait()
+
+	if overlaps != 0 {
+		t.Errorf("Error %d UI requests overlapped", overlaps)
+	}
+}
+
+func Test_Dispatcher_Spinners(t *testing.T) {
+	recorder := &uiRecorder{}
+	c := NewClient(OptClientHandler(recorder.handle))
+
+	a, _ := c.Ux.StartSpinner("a")
+	b, _ := c.Ux.StartSpinner("b")
+	a.Stop("a done")
+	b2, _ := c.Ux.StartSpinner("b2")
+	b2.Stop("b2 done")
+	b.Stop("b done")
+	b.Stop("b done again")
+
+	expected := []string{
+		"start-spinner a",
+		"start-spinner b",
+		"print a done",
+		"start-spinner b2",
+		"stop-spinner b2 done",
+		"start-spinner b",
+		"stop-spinner b done",
+	}
+	if lines := recorder.lines(); !reflect.DeepEqual(lines, expected) {
+		t.Errorf("Error unexpected requests:\n%v\nexpected:\n%v", lines, expected)
+	}
+}
+
+func Test_Dispatcher_LegacySpinner(t *testing.T) {
+	recorder := &uiRecorder{}
+	c := NewClient(OptClientHandler(recorder.handle))
+
+	c.Ux.SpinnerStart("one")
+	c.Ux.SpinnerStart("two")
+	c.Ux.SpinnerStop("done")
+	c.Ux.SpinnerStop("again")
+
+	expected := []string{
+		"start-spinner one",
+		"start-spinner two",
+		"stop-spinner done",
+		"stop-spinner again",
+	}
+	if lines := recorder.lines(); !reflect.DeepEqual(lines, expected) {
+		t.Errorf("Error unexpected requests:\n%v\nexpected:\n%v", lines, expected)
+	}
+}
+
+func Test_Dispatcher_ProgressBars(t *testing.T) {
+	recorder := &uiRecorder{}
+	c := NewClient(OptClientHandler(recorder.handle))
+
+	first, _ := c.Ux.StartProgressBar(5, 0, "first")
+	second, _ := c.Ux.StartProgressBar(2, 0, "second")
+	first.Advance(2)
+	second.Advance(1)
+	second.Stop("second done")
+	first.Stop("")
+
+	expected := []string{
+		"progress-bar/start first 0/5",
+		"progress-bar/start second 0/2",
+		"progress-bar/advance",
+		"progress-bar/stop second done",
+		"progress-bar/start first 2/5",
+		"progress-bar/stop",
+	}
+	if lines := recorder.lines(); !reflect.DeepEqual(lines, expected) {
+		t.Errorf("Error unexpected requests:\n%v\nexpected:\n%v", lines, expected)
+	}
+}
+
+func Test_Dispatcher_QueuePrints(t *testing.T) {
+	recorder := &uiRecorder{}
+	c := NewClient(OptClientHandler(recorder.handle), OptClientQueuePrints(true))
+
+	s, _ := c.Ux.StartSpinner("working")
+	c.Ux.Print("one")
+	c.Ux.Print("two")
+	s.Stop("done")
+	c.Ux.Print("three")
+
+	expected := []string{
+		"start-spinner working",
+		"stop-spinner done",
+		"print one",
+		"print two",
+		"print three",
+	}
+	if lines := recorder.lines(); !reflect.DeepEqual(lines, expected) {
+		t.Errorf("Error unexpected requests:\n%v\nexpected:\n%v", lines, expected)
+	}
+}
+
+func Test_Dispatcher_Cancel(t *testing.T) {
+	release := make(chan struct{})
+	c := NewClient(OptClientHandler(func(ctx context.Context, req *DaemonRequest) (interface{}, error) {
+		<-release
+		return nil, nil
+	}))
+
+	done := make(chan error)
+	go func() {
+		done <- c.Ux.Print("blocking")
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := c.Ux.PrintContext(ctx, "waiting"); err != context.DeadlineExceeded {
+		t.Errorf("Error expected deadline exceeded while waiting, got: %v", err)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Errorf("Error in blocking print: %v", err)
+	}
+	if err := c.Ux.Print("after"); err != nil {
+		t.Errorf("Error printing after cancellation: %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package ctoai
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_Duration_Formats(t *testing.T) {
+	tests := []struct {
+		answer   string
+		expected time.Duration
+	}{
+		{"1h30m", 90 * time.Minute},
+		{"90 minutes", 90 * time.Minute},
+		{"2 days", 48 * time.Hour},
+		{"1 week, 2 days and 3 hours", 9*24*time.Hour + 3*time.Hour},
+		{"2d12h", 60 * time.Hour},
+		{"1.5 Hours", 90 * time.Minute},
+		{"500ms", 500 * time.Millisecond},
+	}
+
+	for _, test := range tests {
+		script := &scriptedPrompts{capabilities: fullCapabilities, answers: []interface{}{test.answer}}
+		p := NewPrompt(OptClientHandler(script.handle))
+
+		d, err := p.Duration("ttl", "How long?")
+		if err != nil {
+			t.Errorf("Error in prompt request for %q: %v", test.answer, err)
+			continue
+		}
+		if d != test.expected {
+			t.Errorf("Error unexpected answer for %q: %v", test.answer, d)
+		}
+	}
+}
+
+func Test_Duration_Reprompt(t *testing.T) {
+	script := &scriptedPrompts{capabilities: fullCapabilities, answers: []interface{}{"soon", "3 fortnights", "30 days", "10 minutes", "2 days"}}
+	p := NewPrompt(OptClientHandler(script.handle), OptClientValidationAttempts(5))
+
+	d, err := p.Duration("ttl", "How long?", OptDurationDefault(24*time.Hour), OptDurationMinimum(time.Hour), OptDurationMaximum(14*24*time.Hour), OptDurationFlag("t"))
+	if err != nil {
+		t.Fatalf("Error in prompt request: %v", err)
+	}
+	if d != 48*time.Hour {
+		t.Errorf("Error unexpected answer: %v", d)
+	}
+
+	expectedDefinition := map[string]interface{}{"name": "ttl", "type": "duration", "message": "How long?", "flag": "t", "default": "24h0m0s", "minimum": "1h0m0s", "maximum": "336h0m0s"}
+	if !reflect.DeepEqual(script.definitions[0], expectedDefinition) {
+		t.Errorf("Error unexpected definition: %v", script.definitions[0])
+	}
+	if script.definitions[1]["default"] != "soon" || script.definitions[3]["default"] != "720h0m0s" {
+		t.Errorf("Error unexpected defaults: %v, %v", script.definitions[1]["default"], script.definitions[3]["default"])
+	}
+	expectedPrints := []string{
+		`"soon" is not a duration such as 1h30m or 90 minutes`,
+		`"3 fortnights" has unknown unit "fortnights"`,
+		"Answer must be at most 336h0m0s",
+		"Answer must be at least 1h0m0s",
+	}
+	if !reflect.DeepEqual(script.prints, expectedPrints) {
+		t.Errorf("Error unexpected prints: %v", script.prints)
+	}
+}
+
+func Test_Duration_Input(t *testing.T) {
+	var caps interface{}
+	json.Unmarshal([]byte(`{"version": "1", "endpoints": ["prompt"], "prompts": ["input"], "features": []}`), &caps)
+	script := &scriptedPrompts{capabilities: caps, answers: []interface{}{"45 mins"}}
+	p := NewPrompt(OptClientHandler(script.handle))
+
+	d, err := p.Duration("window", "Rollback window?", OptDurationDefault(time.Hour))
+	if err != nil {
+		t.Fatalf("Error in prompt request: %v", err)
+	}
+	if d != 45*time.Minute {
+		t.Errorf("Error unexpected answer: %v", d)
+	}
+
+	expectedDefinition := map[string]interface{}{"name": "window", "type": "input", "message": "Rollback window?", "default": "1h0m0s", "allowEmpty": false}
+	if !reflect.DeepEqual(script.definitions[0], expectedDefinition) {
+		t.Errorf("Error unexpected definition: %v", script.definitions[0])
+	}
+}
+
+func Test_Duration_Negative(t *testing.T) {
+	script := &scriptedPrompts{capabilities: fullCapabilities, answers: []interface{}{"-5m", "5m", "-5m"}}
+	p := NewPrompt(OptClientHandler(script.handle))
+
+	d, err := p.Duration("delay", "Delay?")
+	if err != nil {
+		t.Fatalf("Error in prompt request: %v", err)
+	}
+	if d != 5*time.Minute {
+		t.Errorf("Error unexpected answer: %v", d)
+	}
+	if !reflect.DeepEqual(script.prints, []string{"Answer must not be negative"}) {
+		t.Errorf("Error unexpected prints: %v", script.prints)
+	}
+
+	d, err = p.Duration("offset", "Offset?", OptDurationMinimum(-time.Hour))
+	if err != nil {
+		t.Fatalf("Error in prompt request: %v", err)
+	}
+	if d != -5*time.Minute {
+		t.Errorf("Error negative minimum should allow negative answers, got: %v", d)
+	}
+}
+
+func Test_Duration_FormBaseline(t *testing.T) {
+	script := &scriptedPrompts{answers: []interface{}{"garbage", "-5m", "5m"}}
+	c := NewClient(OptClientHandler(script.handle))
+
+	answers, err := c.Prompt.Form().Duration("delay", "Delay?").Run()
+	if err != nil {
+		t.Fatalf("Error in form request: %v", err)
+	}
+	if d := answers.Duration("delay"); d != 5*time.Minute {
+		t.Errorf("Error unexpected answer: %v", d)
+	}
+
+	expectedPrints := []string{`"garbage" is not a duration such as 1h30m or 90 minutes`, "Answer must not be negative"}
+	if !reflect.DeepEqual(script.prints, expectedPrints) {
+		t.Errorf("Error unexpected prints: %v", script.prints)
+	}
+	for _, definition := range script.definitions {
+		if definition["type"] != "input" {
+			t.Errorf("Error expected the baseline input prompt: %v", definition)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package ctoai
+
+import (
+	"errors"
+
+	"github.com/cto-ai/sdk-go/v2/internal/daemon"
+)
+
+// ErrDaemonUnavailable is returned when the SDK daemon cannot be reached,
+// e.g. because the program is not running inside an op.
+//
+// Use errors.Is to check for it, as it is usually wrapped.
+var ErrDaemonUnavailable = daemon.ErrUnavailable
+
+// DaemonError is returned when the SDK daemon responds to a request with
+// an error status code.
+//
+// Use errors.As to retrieve it, as it is usually wrapped.
+type DaemonError = daemon.Error
+
+// ErrUnsafeReplyFile is returned when the reply file named by the daemon is
+// outside the reply directory, is not a regular file, is owned by another
+// user or can be written by other users.
+var ErrUnsafeReplyFile = daemon.ErrUnsafeReplyFile
+
+// ErrUnsupported is returned when the daemon does not support a feature,
+// endpoint or prompt type that a request needs; see Client.Capabilities.
+//
+// Use errors.Is to check for it, or errors.As with an *UnsupportedError to
+// find out what is unsupported.
+var ErrUnsupported = daemon.ErrUnsupported
+
+// UnsupportedError is returned when the daemon does not support a feature,
+// endpoint or prompt type. It matches ErrUnsupported.
+type UnsupportedError = daemon.UnsupportedError
+
+// ErrUnexpectedResponse is returned when the daemon answers a request with
+// a value of the wrong type, e.g. a secret that is not a string.
+//
+// Use errors.Is to check for it, as it is wrapped with details.
+var ErrUnexpectedResponse = errors.New("Daemon returned an unexpected response")
+
+// ErrStateDirNotFound is returned by Sdk.StatePath when SDK_STATE_DIR is unset
+var ErrStateDirNotFound = errors.New("State directory not found in environment var SDK_STATE_DIR")
+
+// ErrConfigDirNotFound is returned by Sdk.ConfigPath when SDK_CONFIG_DIR is unset
+var ErrConfigDirNotFound = errors.New("Config directory not found in environment var SDK_CONFIG_DIR")
+
+// IsNotFound reports whether err is a DaemonError for an item the daemon
+// could not find.
+func IsNotFound(err error) bool {
+	var daemonErr *DaemonError
+	return errors.As(err, &daemonErr) && daemonErr.NotFound()
+}
+
+// IsUserCancelled reports whether err is a DaemonError caused by the user
+// cancelling the request, e.g. by dismissing a prompt.
+func IsUserCancelled(err error) bool {
+	var daemonErr *DaemonError
+	return errors.As(err, &daemonErr) && daemonErr.UserCancelled()
+}
+
+// IsValidation reports whether err is a DaemonError caused by the daemon
+// rejecting the request as invalid.
+func IsValidation(err error) bool {
+	var daemonErr *DaemonError
+	return errors.As(err, &daemonErr) && daemonErr.Validation()
+}
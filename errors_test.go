@@ -0,0 +1,225 @@
+package ctoai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_DaemonUnavailable_NoPort(t *testing.T) {
+	err := os.Unsetenv("SDK_SPEAK_PORT")
+	if err != nil {
+		t.Errorf("Error clearing test env variable: %s", err)
+	}
+
+	// Disable offline mode, which SDK_OFFLINE would turn on
+	c := NewClient(OptClientOffline(false))
+	err = c.Ux.Print("test")
+	if !errors.Is(err, ErrDaemonUnavailable) {
+		t.Errorf("Error expected ErrDaemonUnavailable, got: %v", err)
+	}
+
+	err = os.Setenv("SDK_SPEAK_PORT", "not-a-port")
+	if err != nil {
+		t.Errorf("Error setting test env variable: %s", err)
+	}
+
+	_, err = c.Sdk.GetConfig("test-key")
+	if !errors.Is(err, ErrDaemonUnavailable) {
+		t.Errorf("Error expected ErrDaemonUnavailable, got: %v", err)
+	}
+}
+
+func Test_DaemonUnavailable_Ping(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+
+	c := NewClient(OptClientPort(ServerPort(t, ts)))
+	if err := c.Ping(); err != nil {
+		t.Errorf("Error unexpected ping failure: %v", err)
+	}
+	if !c.Sdk.DaemonAvailable() {
+		t.Errorf("Error daemon should be available")
+	}
+
+	ts.Close()
+
+	if err := c.Ping(); !errors.Is(err, ErrDaemonUnavailable) {
+		t.Errorf("Error expected ErrDaemonUnavailable, got: %v", err)
+	}
+	if c.Sdk.DaemonAvailable() {
+		t.Errorf("Error daemon should not be available")
+	}
+}
+
+func Test_DaemonUnavailable_PingFallback(t *testing.T) {
+	var pingErr error
+	c := NewClient(OptClientHandler(func(ctx context.Context, req *DaemonRequest) (interface{}, error) {
+		if req.Endpoint != "capabilities" {
+			t.Errorf("Error unexpected request to %s", req.Endpoint)
+		}
+		return nil, pingErr
+	}))
+	if !c.Sdk.DaemonAvailable() {
+		t.Errorf("Error handler should be available")
+	}
+
+	pingErr = &DaemonError{StatusCode: http.StatusNotFound, Endpoint: "capabilities", Method: "GET"}
+	if err := c.Ping(); err != nil {
+		t.Errorf("Error unexpected ping failure: %v", err)
+	}
+
+	pingErr = errors.New("down")
+	if err := c.Ping(); !errors.Is(err, ErrDaemonUnavailable) || !errors.Is(err, pingErr) {
+		t.Errorf("Error expected ErrDaemonUnavailable, got: %v", err)
+	}
+}
+
+func Test_Offline_OptIn(t *testing.T) {
+	os.Unsetenv("SDK_SPEAK_PORT")
+	defer os.Unsetenv("SDK_OFFLINE")
+
+	os.Unsetenv("SDK_OFFLINE")
+	if err := NewClient().Ping(); !errors.Is(err, ErrDaemonUnavailable) {
+		t.Errorf("Error offline mode should be off by default, got: %v", err)
+	}
+
+	os.Setenv("SDK_OFFLINE", "1")
+	if err := NewClient().Ping(); err != nil {
+		t.Errorf("Error SDK_OFFLINE should turn on offline mode, got: %v", err)
+	}
+	if err := NewClient(OptClientOffline(false)).Ping(); !errors.Is(err, ErrDaemonUnavailable) {
+		t.Errorf("Error OptClientOffline(false) should disable offline mode, got: %v", err)
+	}
+}
+
+func Test_DaemonError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ValidateRequest(t, r, "/config/set")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"message": "boom"}`))
+	}))
+	defer ts.Close()
+
+	c := NewClient(OptClientPort(ServerPort(t, ts)))
+	err := c.Sdk.SetConfig("key", "value")
+
+	var daemonErr *DaemonError
+	if !errors.As(err, &daemonErr) {
+		t.Fatalf("Error expected DaemonError, got: %v", err)
+	}
+	if daemonErr.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Error unexpected status code: %v", daemonErr.StatusCode)
+	}
+	if daemonErr.Endpoint != "config/set" {
+		t.Errorf("Error unexpected endpoint: %v", daemonErr.Endpoint)
+	}
+	if string(daemonErr.Body) != `{"message": "boom"}` {
+		t.Errorf("Error unexpected body: %s", daemonErr.Body)
+	}
+	if daemonErr.Method != "POST" {
+		t.Errorf("Error unexpected method: %v", daemonErr.Method)
+	}
+	if daemonErr.Message != "boom" {
+		t.Errorf("Error unexpected message: %v", daemonErr.Message)
+	}
+	if !reflect.DeepEqual(daemonErr.Payload, map[string]interface{}{"message": "boom"}) {
+		t.Errorf("Error unexpected payload: %v", daemonErr.Payload)
+	}
+}
+
+func Test_DaemonError_Classification(t *testing.T) {
+	cases := []struct {
+		status        int
+		body          string
+		notFound      bool
+		userCancelled bool
+		validation    bool
+	}{
+		{http.StatusNotFound, `{"message": "no such secret"}`, true, false, false},
+		{http.StatusInternalServerError, `{"message": "no such secret", "code": "not_found"}`, true, false, false},
+		{499, `{}`, false, true, false},
+		{http.StatusBadRequest, `{"error": "cancelled", "code": "user_cancelled"}`, false, true, false},
+		{http.StatusBadRequest, `{"error": "bad prompt type"}`, false, false, true},
+		{http.StatusUnprocessableEntity, `not json`, false, false, true},
+		{http.StatusInternalServerError, `{"message": "boom"}`, false, false, false},
+	}
+
+	for _, tc := range cases {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tc.status)
+			w.Write([]byte(tc.body))
+		}))
+
+		c := NewClient(OptClientPort(ServerPort(t, ts)))
+		_, err := c.Prompt.Input("test", "type test")
+		ts.Close()
+
+		if IsNotFound(err) != tc.notFound {
+			t.Errorf("Error IsNotFound(%v) should be %v", err, tc.notFound)
+		}
+		if IsUserCancelled(err) != tc.userCancelled {
+			t.Errorf("Error IsUserCancelled(%v) should be %v", err, tc.userCancelled)
+		}
+		if IsValidation(err) != tc.validation {
+			t.Errorf("Error IsValidation(%v) should be %v", err, tc.validation)
+		}
+	}
+}
+
+func Test_StatePath(t *testing.T) {
+	err := os.Unsetenv("SDK_STATE_DIR")
+	if err != nil {
+		t.Errorf("Error clearing test env variable: %s", err)
+	}
+
+	_, err = NewSdk().StatePath()
+	if !errors.Is(err, ErrStateDirNotFound) {
+		t.Errorf("Error expected ErrStateDirNotFound, got: %v", err)
+	}
+
+	err = os.Setenv("SDK_STATE_DIR", "/tmp/state")
+	if err != nil {
+		t.Errorf("Error setting test env variable: %s", err)
+	}
+
+	path, err := NewSdk().StatePath()
+	if err != nil || path != "/tmp/state" {
+		t.Errorf("Error unexpected output: %v, %v", path, err)
+	}
+}
+
+func Test_UnexpectedResponse(t *testing.T) {
+	c := NewClient(OptClientHandler(func(ctx context.Context, req *DaemonRequest) (interface{}, error) {
+		switch req.Endpoint {
+		case "secret/get":
+			return map[string]interface{}{"token": []interface{}{"hunter2"}}, nil
+		case "secret/set":
+			return map[string]interface{}{"key": 42.0}, nil
+		}
+		return "not an object", nil
+	}))
+
+	_, err := c.Sdk.GetSecret("token")
+	if !errors.Is(err, ErrUnexpectedResponse) {
+		t.Errorf("Error expected ErrUnexpectedResponse for secret, got: %v", err)
+	}
+	if err != nil && strings.Contains(err.Error(), "hunter2") {
+		t.Errorf("Error should not include the secret value: %v", err)
+	}
+	if _, err := c.Sdk.SetSecret("token", "hunter2"); !errors.Is(err, ErrUnexpectedResponse) {
+		t.Errorf("Error expected ErrUnexpectedResponse for secret key, got: %v", err)
+	}
+	if _, err := c.Sdk.User(); !errors.Is(err, ErrUnexpectedResponse) {
+		t.Errorf("Error expected ErrUnexpectedResponse for user, got: %v", err)
+	}
+	if _, err := c.Sdk.Team(); !errors.Is(err, ErrUnexpectedResponse) {
+		t.Errorf("Error expected ErrUnexpectedResponse for team, got: %v", err)
+	}
+}
@@ -0,0 +1,584 @@
+package ctoai
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/cto-ai/sdk-go/v2/internal/daemon"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// fillKeys are the keys understood in a ctoai struct tag
+var fillKeys = map[string]bool{
+	"name": true, "prompt": true, "msg": true, "flag": true, "choices": true,
+	"default": true, "min": true, "max": true, "step": true, "precision": true,
+	"variant": true,
+}
+
+// fillTarget is a struct field to be set from the answer to a prompt
+type fillTarget struct {
+	name string
+	// index is the path of field indexes to the field from the filled
+	// struct, through nested structs and pointers to them
+	index []int
+	// convert turns the answer into a value of the field's type, or of the
+	// pointed type for pointer fields. It returns the zero Value to leave
+	// the field unset.
+	convert func(answer interface{}) (reflect.Value, error)
+}
+
+// Fill presents a prompt for each tagged field of the struct pointed to by
+// v, all in a single form, and sets the fields to the answers.
+//
+// Fields are described by a ctoai struct tag holding comma-separated
+// key=value pairs:
+//
+//  name       the prompt name, by default the field name with a lowercase initial
+//  prompt     the prompt type, by default inferred from the field type
+//  msg        the message shown to the user, by default the field name
+//  flag       the command line flag matched to the prompt
+//  choices    the choices of a list or checkbox prompt, separated by |
+//  default    the default answer; defaults of checkboxes are separated by |
+//  min        the minimum of a number, datetime or duration prompt, or the
+//             fewest choices selected in a checkbox prompt
+//  max        the maximum of a number, datetime or duration prompt, or the
+//             most choices selected in a checkbox prompt
+//  step       the step between answers of a fractional number prompt
+//  precision  the decimal places accepted by a fractional number prompt
+//  variant    the variant of a datetime prompt: date, time or datetime
+//
+// The msg key may contain commas. Field types map to prompts as follows:
+//
+//  string         input, or list if choices are given; the prompt key may
+//                 also select autocomplete, password, secret or editor
+//  numbers        number, asked with Int64, Uint64 or Float
+//  bool           confirm
+//  []string       checkbox
+//  time.Time      datetime; default, min and max are in RFC 3339 format
+//  time.Duration  duration; default, min and max are durations such as
+//                 5m or 2 days. The prompt key may also select input, to
+//                 leave an optional field nil on an empty answer.
+//
+// A pointer field is optional: it is left nil if the user gives an empty
+// answer to an input prompt, and is otherwise set to a newly allocated
+// value. Nested structs are filled recursively, as are pointers to structs
+// if the field is tagged; a nil pointer is allocated once one of the
+// fields below it is set. If the nested struct field has a name, it
+// prefixes the names of its prompts as in "database.host". Fields tagged
+// ctoai:"-", untagged fields other than structs and structs already being
+// filled further up, as in recursive types, are skipped.
+//
+// Example:
+//
+//  type Params struct {
+//      Service  string        `ctoai:"msg=Which service?,flag=s"`
+//      Region   string        `ctoai:"name=region,choices=us-east-1|eu-west-1,default=us-east-1,msg=Which region?"`
+//      Replicas int           `ctoai:"msg=How many replicas?,default=3,min=1,max=10"`
+//      Timeout  time.Duration `ctoai:"msg=Deploy timeout?,default=5m"`
+//      Canary   *bool         `ctoai:"msg=Roll out as a canary first?"`
+//  }
+//
+//  p := ctoai.NewPrompt()
+//  var params Params
+//  if err := p.Fill(&params); err != nil {
+//      panic(err)
+//  }
+//
+//  fmt.Println(params.Service, params.Region, params.Replicas, params.Timeout)
+//
+// Output:
+// api eu-west-1 3 5m0s
+func (p *Prompt) Fill(v interface{}) error {
+	return p.FillContext(context.Background(), v)
+}
+
+// FillContext is like Fill but uses ctx to cancel or time out the daemon
+// request.
+func (p *Prompt) FillContext(ctx context.Context, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("Fill needs a non-nil pointer to a struct, got %T", v)
+	}
+
+	form := &promptForm{prompt: p}
+	var targets []fillTarget
+	if err := addFillFields(form, &targets, rv.Elem().Type(), nil, "", nil); err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	answers, err := form.RunContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, target := range targets {
+		value, err := target.convert(answers.values[target.name])
+		if err != nil {
+			return fmt.Errorf("Error in answer to %s: %w", target.name, err)
+		}
+		if !value.IsValid() {
+			continue
+		}
+		field := fillField(rv.Elem(), target.index)
+		if field.Kind() == reflect.Ptr {
+			ptr := reflect.New(field.Type().Elem())
+			ptr.Elem().Set(value)
+			value = ptr
+		}
+		field.Set(value)
+	}
+	return nil
+}
+
+// fillField returns the field at index in the struct v, allocating the nil
+// struct pointers on the way
+func fillField(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// addFillFields adds a question to form for each tagged field of the
+// struct type t, found at index, with names prefixed by prefix. filling
+// holds the struct types being filled further up.
+func addFillFields(form *promptForm, targets *[]fillTarget, t reflect.Type, index []int, prefix string, filling []reflect.Type) error {
+	filling = append(filling, t)
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue
+		}
+		tag, tagged := sf.Tag.Lookup("ctoai")
+		if tag == "-" {
+			continue
+		}
+		opts, err := parseFillTag(tag)
+		if err != nil {
+			return fmt.Errorf("Error in tag of field %s: %w", sf.Name, err)
+		}
+
+		fieldIndex := append(append([]int(nil), index...), i)
+		typ := sf.Type
+		optional := typ.Kind() == reflect.Ptr
+		if optional {
+			typ = typ.Elem()
+		}
+
+		if typ.Kind() == reflect.Struct && typ != timeType {
+			if sf.PkgPath != "" || (optional && !tagged) || containsType(filling, typ) {
+				continue
+			}
+			nested := prefix
+			if name, ok := opts["name"]; ok {
+				nested = prefix + name + "."
+			}
+			if err := addFillFields(form, targets, typ, fieldIndex, nested, filling); err != nil {
+				return err
+			}
+			continue
+		}
+		if !tagged || sf.PkgPath != "" {
+			continue
+		}
+
+		name, ok := opts["name"]
+		if !ok {
+			name = lowerInitial(sf.Name)
+		}
+		name = prefix + name
+		msg, ok := opts["msg"]
+		if !ok {
+			msg = sf.Name
+		}
+
+		convert, err := addFillQuestion(form, name, msg, typ, optional, opts)
+		if err != nil {
+			return fmt.Errorf("Error in field %s: %w", sf.Name, err)
+		}
+		*targets = append(*targets, fillTarget{name: name, index: fieldIndex, convert: convert})
+	}
+	return form.err
+}
+
+// containsType reports whether t is in types
+func containsType(types []reflect.Type, t reflect.Type) bool {
+	for _, typ := range types {
+		if typ == t {
+			return true
+		}
+	}
+	return false
+}
+
+// addFillQuestion adds the question for a field of type typ to form and
+// returns the conversion of its answer
+func addFillQuestion(form *promptForm, name, msg string, typ reflect.Type, optional bool, opts map[string]string) (func(interface{}) (reflect.Value, error), error) {
+	promptType := opts["prompt"]
+	if promptType == "" {
+		promptType = inferPromptType(typ, opts)
+	}
+	if !fillPromptFits(promptType, typ) {
+		return nil, fmt.Errorf("%s prompt cannot fill a field of type %s", promptType, typ)
+	}
+	flag := opts["flag"]
+	defaultValue, hasDefault := opts["default"]
+
+	switch promptType {
+	case "input":
+		options := []InputOption{OptInputFlag(flag), OptInputAllowEmpty(optional)}
+		if typ == durationType && hasDefault {
+			if _, err := daemon.ParseDuration(defaultValue); err != nil {
+				return nil, fmt.Errorf("invalid default: %w", err)
+			}
+		}
+		if hasDefault {
+			options = append(options, OptInputDefault(defaultValue))
+		}
+		form.Input(name, msg, options...)
+
+		return func(answer interface{}) (reflect.Value, error) {
+			s, _ := answer.(string)
+			if s == "" && optional {
+				return reflect.Value{}, nil
+			}
+			if typ == durationType {
+				d, err := daemon.ParseDuration(s)
+				if err != nil {
+					return reflect.Value{}, err
+				}
+				return reflect.ValueOf(d), nil
+			}
+			return reflect.ValueOf(s).Convert(typ), nil
+		}, nil
+
+	case "secret":
+		form.Secret(name, msg, OptSecretFlag(flag))
+		return convertString(typ), nil
+
+	case "password":
+		form.Password(name, msg, OptPasswordFlag(flag))
+		return convertString(typ), nil
+
+	case "editor":
+		options := []EditorOption{OptEditorFlag(flag)}
+		if hasDefault {
+			options = append(options, OptEditorDefault(defaultValue))
+		}
+		form.Editor(name, msg, options...)
+		return convertString(typ), nil
+
+	case "list", "autocomplete":
+		choices := splitChoices(opts["choices"])
+		if len(choices) == 0 {
+			return nil, fmt.Errorf("%s prompt needs choices", promptType)
+		}
+		options := []ListOption{OptListFlag(flag), OptListAutocomplete(promptType == "autocomplete")}
+		if hasDefault {
+			options = append(options, OptListDefaultValue(defaultValue))
+		}
+		form.List(name, msg, choices, options...)
+		return convertString(typ), nil
+
+	case "number":
+		switch typ.Kind() {
+		case reflect.Float32, reflect.Float64:
+			options := []FloatOption{OptFloatFlag(flag)}
+			for _, bound := range []struct {
+				key    string
+				option func(float64) FloatOption
+			}{{"default", OptFloatDefault}, {"min", OptFloatMinimum}, {"max", OptFloatMaximum}, {"step", OptFloatStep}} {
+				if s, ok := opts[bound.key]; ok {
+					f, err := strconv.ParseFloat(s, 64)
+					if err != nil {
+						return nil, fmt.Errorf("invalid %s: %w", bound.key, err)
+					}
+					options = append(options, bound.option(f))
+				}
+			}
+			if s, ok := opts["precision"]; ok {
+				n, err := strconv.Atoi(s)
+				if err != nil {
+					return nil, fmt.Errorf("invalid precision: %w", err)
+				}
+				options = append(options, OptFloatPrecision(n))
+			}
+			form.Float(name, msg, options...)
+
+			return func(answer interface{}) (reflect.Value, error) {
+				f, _ := answer.(float64)
+				value := reflect.New(typ).Elem()
+				if value.OverflowFloat(f) {
+					return reflect.Value{}, fmt.Errorf("%v is out of range for %s", f, typ)
+				}
+				value.SetFloat(f)
+				return value, nil
+			}, nil
+
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			options := []Int64Option{OptInt64Flag(flag)}
+			for _, bound := range []struct {
+				key    string
+				option func(int64) Int64Option
+			}{{"default", OptInt64Default}, {"min", OptInt64Minimum}, {"max", OptInt64Maximum}} {
+				if s, ok := opts[bound.key]; ok {
+					n, err := strconv.ParseInt(s, 10, 64)
+					if err != nil {
+						return nil, fmt.Errorf("invalid %s: %w", bound.key, err)
+					}
+					options = append(options, bound.option(n))
+				}
+			}
+			form.Int64(name, msg, options...)
+
+			return func(answer interface{}) (reflect.Value, error) {
+				n, _ := answer.(int64)
+				value := reflect.New(typ).Elem()
+				if value.OverflowInt(n) {
+					return reflect.Value{}, fmt.Errorf("%d is out of range for %s", n, typ)
+				}
+				value.SetInt(n)
+				return value, nil
+			}, nil
+		}
+
+		options := []Uint64Option{OptUint64Flag(flag)}
+		for _, bound := range []struct {
+			key    string
+			option func(uint64) Uint64Option
+		}{{"default", OptUint64Default}, {"min", OptUint64Minimum}, {"max", OptUint64Maximum}} {
+			if s, ok := opts[bound.key]; ok {
+				n, err := strconv.ParseUint(s, 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("invalid %s: %w", bound.key, err)
+				}
+				options = append(options, bound.option(n))
+			}
+		}
+		form.Uint64(name, msg, options...)
+
+		return func(answer interface{}) (reflect.Value, error) {
+			n, _ := answer.(uint64)
+			value := reflect.New(typ).Elem()
+			if value.OverflowUint(n) {
+				return reflect.Value{}, fmt.Errorf("%d is out of range for %s", n, typ)
+			}
+			value.SetUint(n)
+			return value, nil
+		}, nil
+
+	case "confirm":
+		options := []ConfirmOption{OptConfirmFlag(flag)}
+		if hasDefault {
+			b, err := strconv.ParseBool(defaultValue)
+			if err != nil {
+				return nil, fmt.Errorf("invalid default: %w", err)
+			}
+			options = append(options, OptConfirmDefault(b))
+		}
+		form.Confirm(name, msg, options...)
+
+		return func(answer interface{}) (reflect.Value, error) {
+			b, _ := answer.(bool)
+			return reflect.ValueOf(b).Convert(typ), nil
+		}, nil
+
+	case "checkbox":
+		choices := splitChoices(opts["choices"])
+		if len(choices) == 0 {
+			return nil, fmt.Errorf("checkbox prompt needs choices")
+		}
+		options := []CheckboxOption{OptCheckboxFlag(flag)}
+		if hasDefault {
+			options = append(options, OptCheckboxDefaultValues(splitChoices(defaultValue)))
+		}
+		for _, bound := range []struct {
+			key    string
+			option func(int) CheckboxOption
+		}{{"min", OptCheckboxMin}, {"max", OptCheckboxMax}} {
+			if s, ok := opts[bound.key]; ok {
+				n, err := strconv.Atoi(s)
+				if err != nil {
+					return nil, fmt.Errorf("invalid %s: %w", bound.key, err)
+				}
+				options = append(options, bound.option(n))
+			}
+		}
+		form.Checkbox(name, msg, choices, options...)
+
+		return func(answer interface{}) (reflect.Value, error) {
+			values, _ := answer.([]string)
+			slice := reflect.MakeSlice(typ, len(values), len(values))
+			for i, s := range values {
+				slice.Index(i).Set(reflect.ValueOf(s).Convert(typ.Elem()))
+			}
+			return slice, nil
+		}, nil
+
+	case "duration":
+		options := []DurationOption{OptDurationFlag(flag)}
+		for _, bound := range []struct {
+			key    string
+			option func(time.Duration) DurationOption
+		}{{"default", OptDurationDefault}, {"min", OptDurationMinimum}, {"max", OptDurationMaximum}} {
+			if s, ok := opts[bound.key]; ok {
+				d, err := daemon.ParseDuration(s)
+				if err != nil {
+					return nil, fmt.Errorf("invalid %s: %w", bound.key, err)
+				}
+				options = append(options, bound.option(d))
+			}
+		}
+		form.Duration(name, msg, options...)
+
+		return func(answer interface{}) (reflect.Value, error) {
+			d, _ := answer.(time.Duration)
+			return reflect.ValueOf(d), nil
+		}, nil
+
+	case "datetime":
+		options := []DatetimeOption{OptDatetimeFlag(flag)}
+		if variant, ok := opts["variant"]; ok {
+			options = append(options, OptDatetimeVariant(variant))
+		}
+		for _, bound := range []struct {
+			key    string
+			option func(time.Time) DatetimeOption
+		}{{"default", OptDatetimeDefault}, {"min", OptDatetimeMinimum}, {"max", OptDatetimeMaximum}} {
+			if s, ok := opts[bound.key]; ok {
+				t, err := time.Parse(time.RFC3339, s)
+				if err != nil {
+					return nil, fmt.Errorf("invalid %s: %w", bound.key, err)
+				}
+				options = append(options, bound.option(t))
+			}
+		}
+		form.Datetime(name, msg, options...)
+
+		return func(answer interface{}) (reflect.Value, error) {
+			t, _ := answer.(time.Time)
+			return reflect.ValueOf(t), nil
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown prompt type %s", promptType)
+}
+
+// inferPromptType returns the prompt type for a field of type typ
+func inferPromptType(typ reflect.Type, opts map[string]string) string {
+	switch {
+	case typ == timeType:
+		return "datetime"
+	case typ == durationType:
+		return "duration"
+	}
+	switch typ.Kind() {
+	case reflect.String:
+		if opts["choices"] != "" {
+			return "list"
+		}
+		return "input"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Bool:
+		return "confirm"
+	case reflect.Slice:
+		return "checkbox"
+	}
+	return "unsupported"
+}
+
+// fillPromptFits reports whether a prompt of the given type can fill a
+// field of type typ
+func fillPromptFits(promptType string, typ reflect.Type) bool {
+	switch promptType {
+	case "input":
+		return typ == durationType || typ.Kind() == reflect.String
+	case "secret", "password", "editor", "list", "autocomplete":
+		return typ.Kind() == reflect.String
+	case "number":
+		return typ != durationType && inferPromptType(typ, nil) == "number"
+	case "confirm":
+		return typ.Kind() == reflect.Bool
+	case "checkbox":
+		return typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.String
+	case "datetime":
+		return typ == timeType
+	case "duration":
+		return typ == durationType
+	}
+	return false
+}
+
+// convertString converts a string answer to typ
+func convertString(typ reflect.Type) func(interface{}) (reflect.Value, error) {
+	return func(answer interface{}) (reflect.Value, error) {
+		s, _ := answer.(string)
+		return reflect.ValueOf(s).Convert(typ), nil
+	}
+}
+
+// parseFillTag parses a ctoai struct tag into its key=value pairs. A
+// segment that does not start with a known key continues the previous
+// value, so that messages may contain commas.
+func parseFillTag(tag string) (map[string]string, error) {
+	opts := make(map[string]string)
+	if tag == "" {
+		return opts, nil
+	}
+	last := ""
+	for _, segment := range strings.Split(tag, ",") {
+		if i := strings.Index(segment, "="); i >= 0 {
+			key := strings.TrimSpace(segment[:i])
+			if fillKeys[key] {
+				if _, dup := opts[key]; dup {
+					return nil, fmt.Errorf("key %s is set more than once", key)
+				}
+				opts[key] = segment[i+1:]
+				last = key
+				continue
+			}
+			if last != "msg" && !strings.ContainsAny(key, " ?") {
+				return nil, fmt.Errorf("unknown key %s", key)
+			}
+		}
+		if last == "" {
+			return nil, fmt.Errorf("unknown key in %q", segment)
+		}
+		opts[last] += "," + segment
+	}
+	return opts, nil
+}
+
+// splitChoices splits a |-separated list, returning nil for ""
+func splitChoices(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "|")
+}
+
+func lowerInitial(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToLower(r)) + s[size:]
+}
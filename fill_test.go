@@ -0,0 +1,185 @@
+package ctoai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type fillDatabase struct {
+	Host string `ctoai:"msg=Database host?"`
+	Port uint16 `ctoai:"msg=Database port?,default=5432"`
+}
+
+type fillParams struct {
+	Service  string        `ctoai:"msg=Which service, exactly?,flag=s"`
+	Region   string        `ctoai:"name=region,choices=us-east-1|eu-west-1,default=us-east-1,msg=Which region?"`
+	Replicas int8          `ctoai:"msg=How many replicas?,default=3,min=1,max=10"`
+	Canary   *bool         `ctoai:"msg=Canary?"`
+	Tools    []string      `ctoai:"choices=a|b|c,default=a|b"`
+	When     time.Time     `ctoai:"variant=date,min=2020-01-01T00:00:00Z"`
+	Timeout  time.Duration `ctoai:"default=5m"`
+	Token    string        `ctoai:"prompt=password"`
+	Note     *string       `ctoai:"msg=Anything else?"`
+	Database *fillDatabase `ctoai:"name=db"`
+	Ignored  string        `ctoai:"-"`
+	Untagged string
+}
+
+func Test_Fill(t *testing.T) {
+	var definitions []interface{}
+	p := NewPrompt(OptClientHandler(func(ctx context.Context, req *DaemonRequest) (interface{}, error) {
+		if req.Endpoint == "capabilities" {
+			return fullCapabilities, nil
+		}
+		if req.Endpoint != "prompts" {
+			t.Errorf("Error unexpected request to %s", req.Endpoint)
+			return nil, nil
+		}
+		bytes, _ := json.Marshal(req.Body)
+		var body map[string][]interface{}
+		json.Unmarshal(bytes, &body)
+		definitions = body["prompts"]
+
+		return map[string]interface{}{
+			"service":  "api",
+			"region":   "eu-west-1",
+			"replicas": float64(5),
+			"canary":   true,
+			"tools":    []interface{}{"b", "c"},
+			"when":     "2020-01-02T00:00:00Z",
+			"timeout":  "1h30m",
+			"token":    "hunter2",
+			"note":     "",
+			"db.host":  "localhost",
+			"db.port":  float64(5433),
+		}, nil
+	}))
+
+	params := fillParams{Ignored: "kept", Untagged: "kept"}
+	if err := p.Fill(&params); err != nil {
+		t.Fatalf("Error filling struct: %v", err)
+	}
+
+	canary := true
+	expected := fillParams{
+		Service:  "api",
+		Region:   "eu-west-1",
+		Replicas: 5,
+		Canary:   &canary,
+		Tools:    []string{"b", "c"},
+		When:     time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+		Timeout:  90 * time.Minute,
+		Token:    "hunter2",
+		Database: &fillDatabase{Host: "localhost", Port: 5433},
+		Ignored:  "kept",
+		Untagged: "kept",
+	}
+	if !reflect.DeepEqual(params, expected) {
+		t.Errorf("Error unexpected fill:\n%+v\nexpected:\n%+v", params, expected)
+	}
+
+	expectedDefinitions := []interface{}{
+		map[string]interface{}{"name": "service", "type": "input", "message": "Which service, exactly?", "flag": "s", "allowEmpty": false},
+		map[string]interface{}{"name": "region", "type": "list", "message": "Which region?", "choices": []interface{}{"us-east-1", "eu-west-1"}, "default": "us-east-1"},
+		map[string]interface{}{"name": "replicas", "type": "number", "message": "How many replicas?", "default": float64(3), "minimum": float64(1), "maximum": float64(10)},
+		map[string]interface{}{"name": "canary", "type": "confirm", "message": "Canary?", "default": false},
+		map[string]interface{}{"name": "tools", "type": "checkbox", "message": "Tools", "choices": []interface{}{"a", "b", "c"}, "default": []interface{}{"a", "b"}},
+		map[string]interface{}{"name": "when", "type": "datetime", "message": "When", "variant": "date", "minimum": "2020-01-01T00:00:00Z"},
+		map[string]interface{}{"name": "timeout", "type": "duration", "message": "Timeout", "default": "5m0s"},
+		map[string]interface{}{"name": "token", "type": "password", "message": "Token", "confirm": false},
+		map[string]interface{}{"name": "note", "type": "input", "message": "Anything else?", "allowEmpty": true},
+		map[string]interface{}{"name": "db.host", "type": "input", "message": "Database host?", "allowEmpty": false},
+		map[string]interface{}{"name": "db.port", "type": "number", "message": "Database port?", "default": float64(5432), "minimum": float64(0)},
+	}
+	for i, definition := range expectedDefinitions {
+		if i >= len(definitions) || !reflect.DeepEqual(definitions[i], definition) {
+			var got interface{}
+			if i < len(definitions) {
+				got = definitions[i]
+			}
+			t.Errorf("Error unexpected definition %d:\n%v\nexpected:\n%v", i, got, definition)
+		}
+	}
+}
+
+func Test_FillInvalid(t *testing.T) {
+	p := NewPrompt(OptClientHandler(func(ctx context.Context, req *DaemonRequest) (interface{}, error) {
+		return map[string]interface{}{"small": float64(300)}, nil
+	}))
+
+	var notStruct string
+	if err := p.Fill(&notStruct); err == nil {
+		t.Errorf("Error expected a non-struct to be rejected")
+	}
+
+	var unknownKey struct {
+		Value string `ctoai:"nmae=value"`
+	}
+	if err := p.Fill(&unknownKey); err == nil {
+		t.Errorf("Error expected an unknown tag key to be rejected")
+	}
+
+	var mismatch struct {
+		Value int `ctoai:"prompt=list,choices=a|b"`
+	}
+	if err := p.Fill(&mismatch); err == nil {
+		t.Errorf("Error expected a list prompt to be rejected for an int field")
+	}
+
+	var overflow struct {
+		Small int8 `ctoai:""`
+	}
+	if err := p.Fill(&overflow); err == nil {
+		t.Errorf("Error expected an out of range answer to be rejected")
+	}
+}
+
+type fillNode struct {
+	Value string    `ctoai:""`
+	Next  *fillNode `ctoai:"name=next"`
+}
+
+func Test_FillRecursive(t *testing.T) {
+	p := NewPrompt(OptClientHandler(func(ctx context.Context, req *DaemonRequest) (interface{}, error) {
+		if req.Endpoint == "capabilities" {
+			return nil, nil
+		}
+		return map[string]interface{}{"value": "a"}, nil
+	}))
+
+	var node fillNode
+	if err := p.Fill(&node); err != nil {
+		t.Fatalf("Error filling struct: %v", err)
+	}
+	if !reflect.DeepEqual(node, fillNode{Value: "a"}) {
+		t.Errorf("Error unexpected fill: %+v", node)
+	}
+}
+
+func Test_FillUntaggedPointer(t *testing.T) {
+	p := NewPrompt(OptClientHandler(func(ctx context.Context, req *DaemonRequest) (interface{}, error) {
+		if req.Endpoint == "capabilities" {
+			return nil, nil
+		}
+		return map[string]interface{}{"name": "api"}, nil
+	}))
+
+	var params struct {
+		Name     string `ctoai:""`
+		Client   *http.Client
+		Database *fillDatabase
+	}
+	if err := p.Fill(&params); err != nil {
+		t.Fatalf("Error filling struct: %v", err)
+	}
+	if params.Name != "api" {
+		t.Errorf("Error unexpected name %q", params.Name)
+	}
+	if params.Client != nil || params.Database != nil {
+		t.Errorf("Error expected untagged pointer fields to stay nil: %+v", params)
+	}
+}
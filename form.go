@@ -0,0 +1,303 @@
+package ctoai
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cto-ai/sdk-go/v2/internal/daemon"
+)
+
+// Form collects several prompts to be presented to the user in a single
+// round trip to the daemon, e.g. as one Slack message instead of a chain of
+// them.
+//
+// Questions are added with the methods named after the corresponding Prompt
+// methods, which take the same options, and the form is presented with Run.
+// Answers that fail a validation option are asked for again one by one.
+//
+// Form is an interface so that implementations of Prompter other than
+// *Prompt, such as fakes, can provide their own forms.
+type Form interface {
+	Input(name, msg string, options ...InputOption) Form
+	Number(name, msg string, options ...NumberOption) Form
+	Float(name, msg string, options ...FloatOption) Form
+	Int64(name, msg string, options ...Int64Option) Form
+	Uint64(name, msg string, options ...Uint64Option) Form
+	Secret(name, msg string, options ...SecretOption) Form
+	Password(name, msg string, options ...PasswordOption) Form
+	Confirm(name, msg string, options ...ConfirmOption) Form
+	List(name, msg string, choices []string, options ...ListOption) Form
+	Checkbox(name, msg string, choices []string, options ...CheckboxOption) Form
+	Editor(name, msg string, options ...EditorOption) Form
+	Datetime(name, msg string, options ...DatetimeOption) Form
+	Duration(name, msg string, options ...DurationOption) Form
+	Run() (FormAnswers, error)
+	RunContext(ctx context.Context) (FormAnswers, error)
+}
+
+// promptForm is the Form of a *Prompt, which asks the daemon
+type promptForm struct {
+	prompt    *Prompt
+	questions []formQuestion
+	err       error
+}
+
+// formQuestion is a single question of a form with the decoder for its answer
+type formQuestion struct {
+	name       string
+	definition interface{}
+	decode     answerDecoder
+}
+
+// FormAnswers holds the typed answers to a Form, by prompt name
+type FormAnswers struct {
+	values map[string]interface{}
+}
+
+// NewFormAnswers returns the answers to a Form from values typed as
+// returned by the corresponding Prompt methods, e.g. an int for a Number
+// prompt. It is meant for implementations of Form other than the one of
+// *Prompt.
+func NewFormAnswers(values map[string]interface{}) FormAnswers {
+	return FormAnswers{values: values}
+}
+
+// Form starts a new form of prompts to be presented together.
+//
+// Example:
+//
+//  p := ctoai.NewPrompt()
+//  answers, err := p.Form().
+//      Input("service", "Which service do you want to deploy?").
+//      Number("replicas", "How many replicas?", ctoai.OptNumberDefault(3)).
+//      Confirm("canary", "Roll out as a canary first?").
+//      Run()
+//  if err != nil {
+//      panic(err)
+//  }
+//
+//  fmt.Println(answers.String("service"), answers.Int("replicas"), answers.Bool("canary"))
+//
+// Output:
+// api 3 true
+func (p *Prompt) Form() Form {
+	return &promptForm{prompt: p}
+}
+
+func (f *promptForm) add(name string, definition interface{}, decode answerDecoder) *promptForm {
+	for _, question := range f.questions {
+		if question.name == name && f.err == nil {
+			f.err = fmt.Errorf("Form has more than one prompt named %s", name)
+		}
+	}
+	f.questions = append(f.questions, formQuestion{name: name, definition: definition, decode: decode})
+	return f
+}
+
+// Input adds an input prompt to the form; see Prompt.Input.
+func (f *promptForm) Input(name, msg string, options ...InputOption) Form {
+	definition := newInputDefinition(name, msg, options)
+	if _, err := definition.Compile(); err != nil && f.err == nil {
+		f.err = err
+	}
+	return f.add(name, definition, decodeStringAnswer)
+}
+
+// Number adds a number prompt to the form; see Prompt.Number.
+func (f *promptForm) Number(name, msg string, options ...NumberOption) Form {
+	return f.add(name, newNumberDefinition(name, msg, options), decodeNumberAnswer)
+}
+
+// Float adds a float prompt to the form; see Prompt.Float.
+func (f *promptForm) Float(name, msg string, options ...FloatOption) Form {
+	definition := newNumericDefinition(name, msg, true)
+	for _, option := range options {
+		option(&definition)
+	}
+	return f.add(name, definition, decodeFloatAnswer)
+}
+
+// Int64 adds an int64 prompt to the form; see Prompt.Int64.
+func (f *promptForm) Int64(name, msg string, options ...Int64Option) Form {
+	definition := newNumericDefinition(name, msg, false)
+	for _, option := range options {
+		option(&definition)
+	}
+	return f.add(name, definition, decodeInt64Answer)
+}
+
+// Uint64 adds a uint64 prompt to the form; see Prompt.Uint64.
+func (f *promptForm) Uint64(name, msg string, options ...Uint64Option) Form {
+	definition := newNumericDefinition(name, msg, false)
+	definition.Minimum = "0"
+	for _, option := range options {
+		option(&definition)
+	}
+	return f.add(name, definition, decodeUint64Answer)
+}
+
+// Secret adds a secret prompt to the form; see Prompt.Secret.
+func (f *promptForm) Secret(name, msg string, options ...SecretOption) Form {
+	return f.add(name, newSecretDefinition(name, msg, options), decodeStringAnswer)
+}
+
+// Password adds a password prompt to the form; see Prompt.Password.
+func (f *promptForm) Password(name, msg string, options ...PasswordOption) Form {
+	return f.add(name, newPasswordDefinition(name, msg, options), decodeStringAnswer)
+}
+
+// Confirm adds a confirm prompt to the form; see Prompt.Confirm.
+func (f *promptForm) Confirm(name, msg string, options ...ConfirmOption) Form {
+	return f.add(name, newConfirmDefinition(name, msg, options), decodeBoolAnswer)
+}
+
+// List adds a list prompt to the form; see Prompt.List.
+func (f *promptForm) List(name, msg string, choices []string, options ...ListOption) Form {
+	return f.add(name, newListDefinition(name, msg, choices, options), decodeStringAnswer)
+}
+
+// Checkbox adds a checkbox prompt to the form; see Prompt.Checkbox.
+func (f *promptForm) Checkbox(name, msg string, choices []string, options ...CheckboxOption) Form {
+	return f.add(name, newCheckboxDefinition(name, msg, choices, options), decodeStringsAnswer)
+}
+
+// Editor adds an editor prompt to the form; see Prompt.Editor.
+func (f *promptForm) Editor(name, msg string, options ...EditorOption) Form {
+	return f.add(name, newEditorDefinition(name, msg, options), decodeStringAnswer)
+}
+
+// Datetime adds a datetime prompt to the form; see Prompt.Datetime.
+func (f *promptForm) Datetime(name, msg string, options ...DatetimeOption) Form {
+	return f.add(name, newDatetimeDefinition(name, msg, options), decodeDatetimeAnswer)
+}
+
+// Duration adds a duration prompt to the form; see Prompt.Duration.
+func (f *promptForm) Duration(name, msg string, options ...DurationOption) Form {
+	return f.add(name, newDurationDefinition(name, msg, options), decodeDurationAnswer)
+}
+
+// Run presents all the prompts of the form to the user at once and returns
+// their answers.
+func (f *promptForm) Run() (FormAnswers, error) {
+	return f.RunContext(context.Background())
+}
+
+// RunContext is like Run but uses ctx to cancel or time out the daemon
+// request.
+func (f *promptForm) RunContext(ctx context.Context) (FormAnswers, error) {
+	if f.err != nil {
+		return FormAnswers{}, f.err
+	}
+
+	// Negotiate first so that the questions are checked against what the
+	// daemon reported
+	caps, err := f.prompt.transport.Capabilities(ctx)
+	if err != nil {
+		return FormAnswers{}, err
+	}
+	definitions := make([]interface{}, len(f.questions))
+	for i, question := range f.questions {
+		definition, err := f.prompt.negotiate(ctx, question.definition)
+		if err != nil {
+			return FormAnswers{}, err
+		}
+		definitions[i] = definition
+	}
+
+	body, err := f.ask(ctx, caps, definitions)
+	if err != nil {
+		return FormAnswers{}, err
+	}
+
+	answers := FormAnswers{values: make(map[string]interface{}, len(f.questions))}
+	for _, question := range f.questions {
+		value, err := question.decode(body, question.name)
+		if err != nil {
+			return FormAnswers{}, fmt.Errorf("Error in answer to %s: %w", question.name, err)
+		}
+		// Check against the question as written, since the negotiated
+		// definition may be a baseline prompt that lacks its checks
+		value, err = f.prompt.validated(ctx, question.definition, question.name, question.decode, value)
+		if err != nil {
+			return FormAnswers{}, err
+		}
+		answers.values[question.name] = value
+	}
+	return answers, nil
+}
+
+// String returns the answer to an Input, Secret, Password, List or Editor
+// prompt
+func (a FormAnswers) String(name string) string {
+	value, _ := a.values[name].(string)
+	return value
+}
+
+// Int returns the answer to a Number prompt
+func (a FormAnswers) Int(name string) int {
+	value, _ := a.values[name].(int)
+	return value
+}
+
+// Float returns the answer to a Float prompt
+func (a FormAnswers) Float(name string) float64 {
+	value, _ := a.values[name].(float64)
+	return value
+}
+
+// Int64 returns the answer to an Int64 prompt
+func (a FormAnswers) Int64(name string) int64 {
+	value, _ := a.values[name].(int64)
+	return value
+}
+
+// Uint64 returns the answer to a Uint64 prompt
+func (a FormAnswers) Uint64(name string) uint64 {
+	value, _ := a.values[name].(uint64)
+	return value
+}
+
+// Bool returns the answer to a Confirm prompt
+func (a FormAnswers) Bool(name string) bool {
+	value, _ := a.values[name].(bool)
+	return value
+}
+
+// Strings returns the answer to a Checkbox prompt
+func (a FormAnswers) Strings(name string) []string {
+	value, _ := a.values[name].([]string)
+	return value
+}
+
+// Time returns the answer to a Datetime prompt
+func (a FormAnswers) Time(name string) time.Time {
+	value, _ := a.values[name].(time.Time)
+	return value
+}
+
+// Duration returns the answer to a Duration prompt
+func (a FormAnswers) Duration(name string) time.Duration {
+	value, _ := a.values[name].(time.Duration)
+	return value
+}
+
+// ask presents the prompts together if the daemon supports it, and one
+// after the other otherwise
+func (f *promptForm) ask(ctx context.Context, caps daemon.Capabilities, definitions []interface{}) (map[string]interface{}, error) {
+	if caps.SupportsEndpoint("prompts") {
+		return f.prompt.transport.AsyncRequest(ctx, "prompts", daemon.PromptsBody{Prompts: definitions}, "POST")
+	}
+
+	body := make(map[string]interface{}, len(definitions))
+	for _, definition := range definitions {
+		answer, err := f.prompt.transport.AsyncRequest(ctx, "prompt", definition, "POST")
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range answer {
+			body[k] = v
+		}
+	}
+	return body, nil
+}
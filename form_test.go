@@ -0,0 +1,131 @@
+package ctoai
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_PromptRequest_Form(t *testing.T) {
+	expectedResponse := `{"replyFilename": "/tmp/response-mocktest"}`
+	expectedBody := map[string]interface{}{
+		"prompts": []interface{}{
+			map[string]interface{}{"name": "service", "type": "input", "message": "Which service?", "flag": "s", "allowEmpty": false},
+			map[string]interface{}{"name": "replicas", "type": "number", "message": "How many?", "default": float64(3)},
+			map[string]interface{}{"name": "canary", "type": "confirm", "message": "Canary?", "default": false},
+			map[string]interface{}{"name": "region", "type": "list", "message": "Region?", "choices": []interface{}{"us", "eu"}},
+			map[string]interface{}{"name": "tools", "type": "checkbox", "message": "Tools?", "choices": []interface{}{"a", "b"}},
+			map[string]interface{}{"name": "when", "type": "datetime", "message": "When?", "variant": "date"},
+		},
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if HandleFullCapabilities(w, r) {
+			return
+		}
+		ValidateRequest(t, r, "/prompts")
+
+		var tmp map[string]interface{}
+		err := json.NewDecoder(r.Body).Decode(&tmp)
+		if err != nil {
+			t.Errorf("Error in decoding response body: %s", err)
+		}
+
+		if !reflect.DeepEqual(tmp, expectedBody) {
+			t.Errorf("Error unexpected request body: %+v", tmp)
+		}
+
+		fmt.Fprintf(w, expectedResponse)
+	}))
+
+	defer ts.Close()
+
+	// write a fake file
+	err := ioutil.WriteFile("/tmp/response-mocktest", []byte(`{
+		"service": "api",
+		"replicas": 5,
+		"canary": true,
+		"region": "eu",
+		"tools": ["a", "b"],
+		"when": "2020-01-02T00:00:00Z"
+	}`), 0600)
+	if err != nil {
+		t.Fatalf("Error writing reply file: %v", err)
+	}
+
+	SetPortVar(t, ts)
+
+	p := NewPrompt()
+	answers, err := p.Form().
+		Input("service", "Which service?", OptInputFlag("s")).
+		Number("replicas", "How many?", OptNumberDefault(3)).
+		Confirm("canary", "Canary?").
+		List("region", "Region?", []string{"us", "eu"}).
+		Checkbox("tools", "Tools?", []string{"a", "b"}).
+		Datetime("when", "When?", OptDatetimeVariant(DATE)).
+		Run()
+	if err != nil {
+		t.Fatalf("Error in prompt request: %v", err)
+	}
+
+	if answers.String("service") != "api" {
+		t.Errorf("Error unexpected service: %v", answers.String("service"))
+	}
+	if answers.Int("replicas") != 5 {
+		t.Errorf("Error unexpected replicas: %v", answers.Int("replicas"))
+	}
+	if !answers.Bool("canary") {
+		t.Errorf("Error unexpected canary: %v", answers.Bool("canary"))
+	}
+	if answers.String("region") != "eu" {
+		t.Errorf("Error unexpected region: %v", answers.String("region"))
+	}
+	if !reflect.DeepEqual(answers.Strings("tools"), []string{"a", "b"}) {
+		t.Errorf("Error unexpected tools: %v", answers.Strings("tools"))
+	}
+	if !answers.Time("when").Equal(time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Error unexpected when: %v", answers.Time("when"))
+	}
+}
+
+func Test_PromptRequest_FormInvalid(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if HandleFullCapabilities(w, r) {
+			return
+		}
+		ValidateRequest(t, r, "/prompts")
+		fmt.Fprintf(w, `{"replyFilename": "/tmp/response-mocktest"}`)
+	}))
+
+	defer ts.Close()
+
+	SetPortVar(t, ts)
+
+	p := NewPrompt()
+	_, err := p.Form().Input("name", "first").Input("name", "second").Run()
+	if err == nil {
+		t.Errorf("Error expected duplicate prompt names to be rejected")
+	}
+
+	err = ioutil.WriteFile("/tmp/response-mocktest", []byte(`{"count": "many"}`), 0600)
+	if err != nil {
+		t.Fatalf("Error writing reply file: %v", err)
+	}
+
+	_, err = p.Form().Number("count", "How many?").Run()
+	if err == nil {
+		t.Errorf("Error expected non-numeric answer to be rejected")
+	}
+}
+
+func Test_NewFormAnswers(t *testing.T) {
+	answers := NewFormAnswers(map[string]interface{}{"service": "api", "replicas": 3})
+	if answers.String("service") != "api" || answers.Int("replicas") != 3 || answers.Bool("canary") {
+		t.Errorf("Error unexpected answers: %+v", answers)
+	}
+}
@@ -0,0 +1,153 @@
+package ctoai
+
+import (
+	"context"
+	"time"
+)
+
+// Prompter asks the user questions. It is implemented by *Prompt and can be
+// swapped in a Client with OptClientPrompter, e.g. for a fake from the
+// ctoaitest package.
+type Prompter interface {
+	Input(name, msg string, options ...InputOption) (string, error)
+	InputContext(ctx context.Context, name, msg string, options ...InputOption) (string, error)
+	Number(name, msg string, options ...NumberOption) (int, error)
+	NumberContext(ctx context.Context, name, msg string, options ...NumberOption) (int, error)
+	Float(name, msg string, options ...FloatOption) (float64, error)
+	FloatContext(ctx context.Context, name, msg string, options ...FloatOption) (float64, error)
+	Int64(name, msg string, options ...Int64Option) (int64, error)
+	Int64Context(ctx context.Context, name, msg string, options ...Int64Option) (int64, error)
+	Uint64(name, msg string, options ...Uint64Option) (uint64, error)
+	Uint64Context(ctx context.Context, name, msg string, options ...Uint64Option) (uint64, error)
+	Secret(name, msg string, options ...SecretOption) (string, error)
+	SecretContext(ctx context.Context, name, msg string, options ...SecretOption) (string, error)
+	Password(name, msg string, options ...PasswordOption) (string, error)
+	PasswordContext(ctx context.Context, name, msg string, options ...PasswordOption) (string, error)
+	Confirm(name, msg string, options ...ConfirmOption) (bool, error)
+	ConfirmContext(ctx context.Context, name, msg string, options ...ConfirmOption) (bool, error)
+	List(name, msg string, choices []string, options ...ListOption) (string, error)
+	ListContext(ctx context.Context, name, msg string, choices []string, options ...ListOption) (string, error)
+	Checkbox(name, msg string, choices []string, options ...CheckboxOption) ([]string, error)
+	CheckboxContext(ctx context.Context, name, msg string, choices []string, options ...CheckboxOption) ([]string, error)
+	ListChoices(name, msg string, choices []Choice, options ...ListOption) (interface{}, error)
+	ListChoicesContext(ctx context.Context, name, msg string, choices []Choice, options ...ListOption) (interface{}, error)
+	CheckboxChoices(name, msg string, choices []Choice, options ...CheckboxOption) ([]interface{}, error)
+	CheckboxChoicesContext(ctx context.Context, name, msg string, choices []Choice, options ...CheckboxOption) ([]interface{}, error)
+	CheckboxIndexes(name, msg string, choices []string, options ...CheckboxOption) ([]int, []string, error)
+	CheckboxIndexesContext(ctx context.Context, name, msg string, choices []string, options ...CheckboxOption) ([]int, []string, error)
+	Autocomplete(name, msg string, source AutocompleteSource, options ...AutocompleteOption) (interface{}, error)
+	AutocompleteContext(ctx context.Context, name, msg string, source AutocompleteSource, options ...AutocompleteOption) (interface{}, error)
+	Editor(name, msg string, options ...EditorOption) (string, error)
+	EditorContext(ctx context.Context, name, msg string, options ...EditorOption) (string, error)
+	Datetime(name, msg string, options ...DatetimeOption) (time.Time, error)
+	DatetimeContext(ctx context.Context, name, msg string, options ...DatetimeOption) (time.Time, error)
+	Duration(name, msg string, options ...DurationOption) (time.Duration, error)
+	DurationContext(ctx context.Context, name, msg string, options ...DurationOption) (time.Duration, error)
+	Form() Form
+	Fill(v interface{}) error
+	FillContext(ctx context.Context, v interface{}) error
+}
+
+// UX renders output on the user's interface. It is implemented by *Ux and
+// can be swapped in a Client with OptClientUX.
+type UX interface {
+	Bold(text string) string
+	Italic(text string) string
+	Print(text string) error
+	PrintContext(ctx context.Context, text string) error
+	SpinnerStart(text string) error
+	SpinnerStartContext(ctx context.Context, text string) error
+	SpinnerStop(text string) error
+	SpinnerStopContext(ctx context.Context, text string) error
+	ProgressBarStart(length, initial int, message string) error
+	ProgressBarStartContext(ctx context.Context, length, initial int, message string) error
+	ProgressBarAdvance(increment int) error
+	ProgressBarAdvanceContext(ctx context.Context, increment int) error
+	ProgressBarStop(message string) error
+	ProgressBarStopContext(ctx context.Context, message string) error
+	StartSpinner(text string) (Spinner, error)
+	StartSpinnerContext(ctx context.Context, text string) (Spinner, error)
+	StartProgressBar(length, initial int, message string) (ProgressBar, error)
+	StartProgressBarContext(ctx context.Context, length, initial int, message string) (ProgressBar, error)
+}
+
+// Spinner is a spinner started with UX.StartSpinner. Only one spinner is
+// shown at a time: the one started last. When it stops, the spinner
+// started before it is shown again.
+type Spinner interface {
+	// Stop stops the spinner, showing text in its place. Stopping a
+	// spinner that is not shown, because another was started after it,
+	// prints text instead. Stopping a spinner twice has no effect.
+	Stop(text string) error
+	// StopContext is like Stop but uses ctx to cancel or time out the
+	// daemon request.
+	StopContext(ctx context.Context, text string) error
+}
+
+// ProgressBar is a progress bar started with UX.StartProgressBar. Only one
+// progress bar is shown at a time: the one started last. The others keep
+// track of their progress and are shown again once it stops.
+type ProgressBar interface {
+	// Advance fills increment more units of the progress bar
+	Advance(increment int) error
+	// AdvanceContext is like Advance but uses ctx to cancel or time out
+	// the daemon request.
+	AdvanceContext(ctx context.Context, increment int) error
+	// Stop completes the progress bar, replacing its text with message if
+	// it is not empty. Stopping a progress bar that is not shown prints
+	// message instead. Stopping a progress bar twice has no effect.
+	Stop(message string) error
+	// StopContext is like Stop but uses ctx to cancel or time out the
+	// daemon request.
+	StopContext(ctx context.Context, message string) error
+}
+
+// Platform gives access to the Ops Platform: config, state and secret
+// stores, tracking, events and the current user and team. It is implemented
+// by *Sdk and can be swapped in a Client with OptClientPlatform.
+type Platform interface {
+	GetHostOS() string
+	GetInterfaceType() string
+	HomeDir() string
+	GetStatePath() string
+	StatePath() (string, error)
+	GetConfigPath() string
+	ConfigPath() (string, error)
+	DaemonAvailable() bool
+	DaemonAvailableContext(ctx context.Context) bool
+	GetState(key string) (interface{}, error)
+	GetStateContext(ctx context.Context, key string) (interface{}, error)
+	GetAllState() (map[string]interface{}, error)
+	GetAllStateContext(ctx context.Context) (map[string]interface{}, error)
+	SetState(key string, value interface{}) error
+	SetStateContext(ctx context.Context, key string, value interface{}) error
+	GetConfig(key string) (string, error)
+	GetConfigContext(ctx context.Context, key string) (string, error)
+	GetAllConfig() (map[string]string, error)
+	GetAllConfigContext(ctx context.Context) (map[string]string, error)
+	SetConfig(key string, value string) error
+	SetConfigContext(ctx context.Context, key string, value string) error
+	DeleteConfig(key string) (bool, error)
+	DeleteConfigContext(ctx context.Context, key string) (bool, error)
+	GetSecret(key string, options ...GetSecretOption) (string, error)
+	GetSecretContext(ctx context.Context, key string, options ...GetSecretOption) (string, error)
+	SetSecret(key string, value string) (string, error)
+	SetSecretContext(ctx context.Context, key string, value string) (string, error)
+	Track(tags []string, event string, metadata map[string]interface{}) error
+	TrackContext(ctx context.Context, tags []string, event string, metadata map[string]interface{}) error
+	Start(workflowName string) error
+	StartContext(ctx context.Context, workflowName string) error
+	Events(start, end string) ([]map[string]interface{}, error)
+	EventsContext(ctx context.Context, start, end string) ([]map[string]interface{}, error)
+	User() (UserInfo, error)
+	UserContext(ctx context.Context) (UserInfo, error)
+	Log(message string) error
+	Team() (TeamInfo, error)
+	TeamContext(ctx context.Context) (TeamInfo, error)
+}
+
+var (
+	_ Prompter = (*Prompt)(nil)
+	_ UX       = (*Ux)(nil)
+	_ Platform = (*Sdk)(nil)
+)
@@ -0,0 +1,47 @@
+package ctoai
+
+import (
+	"context"
+	"testing"
+)
+
+type stubPrompter struct {
+	*Prompt
+}
+
+func (stubPrompter) Input(name, msg string, options ...InputOption) (string, error) {
+	return "stubbed", nil
+}
+
+func Test_OptClientPrompter(t *testing.T) {
+	c := NewClient(OptClientPrompter(stubPrompter{}))
+
+	answer, err := c.Prompt.Input("name", "Name?")
+	if err != nil || answer != "stubbed" {
+		t.Errorf("Error expected stubbed answer, got: %v, %v", answer, err)
+	}
+	if _, ok := c.Ux.(*Ux); !ok {
+		t.Errorf("Error expected default Ux, got %T", c.Ux)
+	}
+}
+
+func Test_OptClientHandler(t *testing.T) {
+	var endpoints []string
+	c := NewClient(OptClientHandler(func(ctx context.Context, req *DaemonRequest) (interface{}, error) {
+		endpoints = append(endpoints, req.Endpoint)
+		if req.Kind == RequestAsync {
+			return map[string]interface{}{"name": "handled"}, nil
+		}
+		return nil, nil
+	}))
+
+	if err := c.Ux.Print("hello"); err != nil {
+		t.Errorf("Error printing test value: %v", err)
+	}
+	if answer, err := c.Prompt.Input("name", "Name?"); err != nil || answer != "handled" {
+		t.Errorf("Error unexpected answer: %v, %v", answer, err)
+	}
+	if len(endpoints) != 2 || endpoints[0] != "print" || endpoints[1] != "prompt" {
+		t.Errorf("Error unexpected endpoints: %v", endpoints)
+	}
+}
@@ -0,0 +1,277 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ProtocolVersion is the version of the daemon protocol spoken by this SDK
+const ProtocolVersion = "1"
+
+// Features that a daemon may or may not support, beyond its endpoints and
+// prompt types
+const (
+	FeaturePasswordConfirm     = "password.confirm"
+	FeatureDatetimeVariant     = "datetime.variant"
+	FeatureNumberFloat         = "number.float"
+	FeatureChoicesLabeled      = "choices.labeled"
+	FeatureAutocompleteDynamic = "autocomplete.dynamic"
+)
+
+// ErrUnsupported is returned when the daemon does not support a feature
+// that a request needs.
+var ErrUnsupported = errors.New("not supported by the daemon")
+
+// UnsupportedError is returned when the daemon does not support a feature,
+// endpoint or prompt type. It matches ErrUnsupported with errors.Is.
+type UnsupportedError struct {
+	// Feature names what is unsupported, e.g. "endpoint events",
+	// "prompt editor" or "feature password.confirm"
+	Feature string
+	// Version is the protocol version reported by the daemon
+	Version string
+}
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("%s is %v (protocol version %s)", e.Feature, ErrUnsupported, e.Version)
+}
+
+func (e *UnsupportedError) Is(target error) bool {
+	return target == ErrUnsupported
+}
+
+// Capabilities describes the protocol version and features of a daemon.
+//
+// Daemons that predate capability negotiation report no version; they are
+// assumed to support only the baseline endpoints, prompt types and
+// features that every daemon serves, see BaselineCapabilities.
+type Capabilities struct {
+	// Version is the daemon's protocol version, or "" if it is unknown
+	Version string `json:"version"`
+	// Endpoints lists the endpoints the daemon serves, e.g. "events"
+	Endpoints []string `json:"endpoints"`
+	// Prompts lists the prompt types the daemon can present, e.g. "editor"
+	Prompts []string `json:"prompts"`
+	// Features lists optional behaviours such as "password.confirm"
+	Features []string `json:"features"`
+}
+
+// Known reports whether the daemon reported its capabilities
+func (c Capabilities) Known() bool {
+	return c.Version != ""
+}
+
+// SupportsEndpoint reports whether the daemon serves endpoint
+func (c Capabilities) SupportsEndpoint(endpoint string) bool {
+	return contains(c.reported().Endpoints, endpoint)
+}
+
+// SupportsPrompt reports whether the daemon can present prompts of the
+// given type
+func (c Capabilities) SupportsPrompt(promptType string) bool {
+	return contains(c.reported().Prompts, promptType)
+}
+
+// SupportsFeature reports whether the daemon supports an optional feature
+func (c Capabilities) SupportsFeature(feature string) bool {
+	return contains(c.reported().Features, feature)
+}
+
+// reported returns the capabilities reported by the daemon, or the
+// baseline if it reported none
+func (c Capabilities) reported() Capabilities {
+	if c.Known() {
+		return c
+	}
+	return BaselineCapabilities()
+}
+
+// RequireEndpoint returns an *UnsupportedError if the daemon does not serve
+// endpoint
+func (c Capabilities) RequireEndpoint(endpoint string) error {
+	if c.SupportsEndpoint(endpoint) {
+		return nil
+	}
+	return &UnsupportedError{Feature: "endpoint " + endpoint, Version: c.Version}
+}
+
+// RequirePrompt returns an *UnsupportedError if the daemon cannot present
+// prompts of the given type
+func (c Capabilities) RequirePrompt(promptType string) error {
+	if c.SupportsPrompt(promptType) {
+		return nil
+	}
+	return &UnsupportedError{Feature: "prompt " + promptType, Version: c.Version}
+}
+
+// RequireFeature returns an *UnsupportedError if the daemon does not
+// support feature
+func (c Capabilities) RequireFeature(feature string) error {
+	if c.SupportsFeature(feature) {
+		return nil
+	}
+	return &UnsupportedError{Feature: "feature " + feature, Version: c.Version}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// BaselineCapabilities returns the capabilities of a daemon that predates
+// capability negotiation. It reports no version.
+func BaselineCapabilities() Capabilities {
+	return Capabilities{
+		Endpoints: []string{
+			"prompt", "print",
+			"start-spinner", "stop-spinner",
+			"progress-bar/start", "progress-bar/advance", "progress-bar/stop",
+			"config/get", "config/get-all", "config/set", "config/delete",
+			"state/get", "state/get-all", "state/set",
+			"secret/get", "secret/set",
+			"track", "events", "user", "team",
+		},
+		Prompts: []string{
+			"input", "number", "secret", "password", "confirm",
+			"list", "autocomplete", "checkbox", "editor", "datetime",
+		},
+		Features: []string{FeaturePasswordConfirm, FeatureDatetimeVariant},
+	}
+}
+
+// FullCapabilities returns the capabilities of a daemon supporting
+// everything this SDK uses, reporting the given protocol version
+func FullCapabilities(version string) Capabilities {
+	return Capabilities{
+		Version: version,
+		Endpoints: []string{
+			"capabilities", "prompt", "prompts", "print",
+			"start-spinner", "stop-spinner",
+			"progress-bar/start", "progress-bar/advance", "progress-bar/stop",
+			"config/get", "config/get-all", "config/set", "config/delete",
+			"state/get", "state/get-all", "state/set",
+			"secret/get", "secret/set",
+			"track", "events", "user", "team",
+			"autocomplete/query", "autocomplete/choices",
+		},
+		Prompts: []string{
+			"input", "number", "secret", "password", "confirm",
+			"list", "autocomplete", "checkbox", "editor", "datetime",
+			"duration",
+		},
+		Features: []string{
+			FeaturePasswordConfirm, FeatureDatetimeVariant,
+			FeatureNumberFloat, FeatureChoicesLabeled,
+			FeatureAutocompleteDynamic,
+		},
+	}
+}
+
+// fetchingCapabilities marks the context of a capabilities request, so
+// that middleware making SDK calls while it is in flight do not wait for it
+type fetchingCapabilities struct{}
+
+// Capabilities asks the daemon for its capabilities. The answer is cached,
+// so the daemon is only asked once per Client; concurrent callers wait for
+// the request in flight, without holding up other requests.
+//
+// A daemon that does not know the capabilities endpoint is assumed to
+// support the baseline, see BaselineCapabilities. So are calls made by
+// middleware while handling the capabilities request itself.
+func (c *Client) Capabilities(ctx context.Context) (Capabilities, error) {
+	if c == nil {
+		c = defaultClient
+	}
+	if ctx.Value(fetchingCapabilities{}) != nil {
+		return c.NegotiatedCapabilities(), nil
+	}
+
+	for {
+		c.capsMu.Lock()
+		if c.caps != nil {
+			caps := *c.caps
+			c.capsMu.Unlock()
+			return caps, nil
+		}
+		fetch := c.capsFetch
+		if fetch == nil {
+			break
+		}
+		c.capsMu.Unlock()
+
+		select {
+		case <-fetch:
+		case <-ctx.Done():
+			return Capabilities{}, ctx.Err()
+		}
+	}
+	fetch := make(chan struct{})
+	c.capsFetch = fetch
+	c.capsMu.Unlock()
+
+	caps, err := c.fetchCapabilities(context.WithValue(ctx, fetchingCapabilities{}, true))
+
+	c.capsMu.Lock()
+	if err == nil {
+		c.caps = &caps
+	}
+	c.capsFetch = nil
+	c.capsMu.Unlock()
+	close(fetch)
+	return caps, err
+}
+
+// fetchCapabilities sends the capabilities request to the daemon
+func (c *Client) fetchCapabilities(ctx context.Context) (Capabilities, error) {
+	value, err := c.handler()(ctx, &Request{Kind: Sync, Endpoint: "capabilities", Method: "GET"})
+	var daemonErr *Error
+	if errors.As(err, &daemonErr) && daemonErr.StatusCode == http.StatusNotFound {
+		value, err = nil, nil
+	}
+	if err != nil {
+		return Capabilities{}, err
+	}
+
+	var caps Capabilities
+	if value != nil {
+		bytes, err := json.Marshal(value)
+		if err != nil {
+			return Capabilities{}, fmt.Errorf("Error marshalling capabilities: %w", err)
+		}
+		if err := json.Unmarshal(bytes, &caps); err != nil {
+			return Capabilities{}, fmt.Errorf("Error decoding capabilities %s: %w", bytes, err)
+		}
+	}
+	return caps, nil
+}
+
+// NegotiatedCapabilities returns the capabilities if they have already
+// been negotiated, and otherwise the baseline. Unlike Capabilities, it
+// never asks the daemon.
+func (c *Client) NegotiatedCapabilities() Capabilities {
+	if c == nil {
+		c = defaultClient
+	}
+	if caps, ok := c.cachedCapabilities(); ok {
+		return caps
+	}
+	return BaselineCapabilities()
+}
+
+// cachedCapabilities returns the capabilities if they have already been
+// negotiated. It does not wait for a capabilities request in flight.
+func (c *Client) cachedCapabilities() (Capabilities, bool) {
+	c.capsMu.Lock()
+	defer c.capsMu.Unlock()
+	if c.caps == nil {
+		return Capabilities{}, false
+	}
+	return *c.caps, true
+}
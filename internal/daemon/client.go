@@ -0,0 +1,216 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const defaultHost = "127.0.0.1"
+
+// defaultClient serves requests made through a nil *Client, such as those
+// from a zero-value Prompt, Ux or Sdk.
+var defaultClient = New()
+
+// Client is the transport used to talk to the SDK daemon.
+//
+// The zero value is not usable; create one with New.
+type Client struct {
+	host       string
+	port       int
+	socket     string
+	httpClient *http.Client
+	// roundTripper replaces the Transport of httpClient if set
+	roundTripper http.RoundTripper
+	retry        *RetryPolicy
+
+	replyDir     string
+	replyTimeout time.Duration
+	replyMaxSize int64
+
+	mu         sync.RWMutex
+	middleware []Middleware
+	debug      io.Writer
+	debugLog   *debugLogger
+
+	capsMu sync.Mutex
+	caps   *Capabilities
+	// capsFetch is closed once the capabilities request in flight, if
+	// any, completes
+	capsFetch chan struct{}
+
+	fallback      Handler
+	forceFallback bool
+
+	socketMu     sync.Mutex
+	socketPath   string
+	socketClient *http.Client
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHost sets the host the daemon is listening on. Defaults to 127.0.0.1.
+func WithHost(host string) Option {
+	return func(c *Client) {
+		c.host = host
+	}
+}
+
+// WithPort sets the port the daemon is listening on.
+//
+// If unset, the port is read from SDK_SPEAK_PORT on every request.
+func WithPort(port int) Option {
+	return func(c *Client) {
+		c.port = port
+	}
+}
+
+// WithSocket makes the client talk to the daemon over the Unix domain socket
+// at path instead of TCP.
+//
+// If neither a socket nor a port is set, the socket path is read from
+// SDK_SPEAK_SOCKET on every request, falling back to SDK_SPEAK_PORT if it is
+// unset.
+func WithSocket(path string) Option {
+	return func(c *Client) {
+		c.socket = path
+	}
+}
+
+// WithFallback serves requests with h instead of the daemon.
+//
+// If force is false, h is only used when no daemon is configured, i.e. when
+// neither a port nor a socket has been set through options or the
+// SDK_SPEAK_PORT and SDK_SPEAK_SOCKET environment variables. A nil h
+// disables the fallback.
+func WithFallback(h Handler, force bool) Option {
+	return func(c *Client) {
+		c.fallback = h
+		c.forceFallback = force
+	}
+}
+
+// useFallback reports whether requests should be served by the fallback
+func (c *Client) useFallback() bool {
+	if c.fallback == nil {
+		return false
+	}
+	if c.forceFallback {
+		return true
+	}
+	return c.port == 0 && c.socket == "" && os.Getenv("SDK_SPEAK_PORT") == "" && os.Getenv("SDK_SPEAK_SOCKET") == ""
+}
+
+// WithHTTPClient sets the http.Client used for daemon requests.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithRoundTripper sets the http.RoundTripper used for daemon requests.
+//
+// It replaces the Transport of the configured http.Client, whichever order
+// the options are given in; the http.Client is copied rather than
+// modified.
+func WithRoundTripper(rt http.RoundTripper) Option {
+	return func(c *Client) {
+		c.roundTripper = rt
+	}
+}
+
+// New creates a daemon Client with the given options applied
+func New(options ...Option) *Client {
+	c := &Client{
+		host:       defaultHost,
+		httpClient: http.DefaultClient,
+		debug:      debugFromEnv(),
+	}
+	for _, option := range options {
+		option(c)
+	}
+	if c.roundTripper != nil {
+		httpClient := *c.httpClient
+		httpClient.Transport = c.roundTripper
+		c.httpClient = &httpClient
+	}
+	if c.debug != nil {
+		c.debugLog = &debugLogger{w: c.debug}
+	}
+	return c
+}
+
+// resolveSocket returns the socket path to dial, or "" to use TCP
+func (c *Client) resolveSocket() string {
+	if c.socket != "" || c.port != 0 {
+		return c.socket
+	}
+	return os.Getenv("SDK_SPEAK_SOCKET")
+}
+
+// target returns the URL for endpoint and the http.Client to request it with
+func (c *Client) target(endpoint string) (string, *http.Client, error) {
+	if socket := c.resolveSocket(); socket != "" {
+		// The host is ignored when dialing the socket, but must be valid
+		return fmt.Sprintf("http://unix/%s", endpoint), c.httpClientForSocket(socket), nil
+	}
+
+	port, err := c.resolvePort()
+	if err != nil {
+		return "", nil, err
+	}
+	return fmt.Sprintf("http://%s/%s", net.JoinHostPort(c.host, strconv.Itoa(port)), endpoint), c.httpClient, nil
+}
+
+// httpClientForSocket returns an http.Client that dials the Unix socket at
+// path. A custom Transport set by the user is used as is, since it is
+// responsible for its own dialing.
+func (c *Client) httpClientForSocket(path string) *http.Client {
+	if c.httpClient.Transport != nil {
+		return c.httpClient
+	}
+
+	c.socketMu.Lock()
+	defer c.socketMu.Unlock()
+
+	if c.socketClient == nil || c.socketPath != path {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.Proxy = nil
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", path)
+		}
+
+		httpClient := *c.httpClient
+		httpClient.Transport = transport
+		c.socketClient = &httpClient
+		c.socketPath = path
+	}
+	return c.socketClient
+}
+
+func (c *Client) resolvePort() (int, error) {
+	if c.port != 0 {
+		return c.port, nil
+	}
+
+	portStr := os.Getenv("SDK_SPEAK_PORT")
+	if portStr == "" {
+		return 0, &unavailableError{errors.New("neither SDK_SPEAK_SOCKET nor SDK_SPEAK_PORT is set")}
+	}
+
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return 0, &unavailableError{fmt.Errorf("SDK_SPEAK_PORT is malformed: %w", err)}
+	}
+
+	return port, nil
+}
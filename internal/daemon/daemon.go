@@ -2,76 +2,108 @@ package daemon
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
-	"os"
-	"strconv"
 )
 
-const badPortMsg = "The CTO.ai Ops SDK requires a daemon process to be running; this does not appear to be the case."
+// daemonRequest performs req over HTTP, recording the response status code
+// in req.
+func (c *Client) daemonRequest(ctx context.Context, req *Request) (*http.Response, error) {
+	endpoint, body, method := req.Endpoint, req.Body, req.Method
 
-func port() int {
-	portStr := os.Getenv("SDK_SPEAK_PORT")
-	if portStr == "" {
-		panic(badPortMsg)
-	}
-
-	port, err := strconv.Atoi(portStr)
+	url, httpClient, err := c.target(endpoint)
 	if err != nil {
-		panic(badPortMsg)
+		return nil, err
 	}
 
-	return port
-}
-
-func daemonRequest(endpoint string, body interface{}, method string) (*http.Response, error) {
 	bodyBytes, err := json.Marshal(body)
 	if err != nil {
 		return nil, fmt.Errorf("Error marshalling JSON body: %w", err)
 	}
 
-	var resp *http.Response
+	for attempt := 1; ; attempt++ {
+		resp, err := c.roundTrip(ctx, httpClient, url, endpoint, method, bodyBytes)
+		req.StatusCode = statusCode(resp, err)
+		if err == nil || !c.retry.shouldRetry(ctx, endpoint, attempt, err) {
+			return resp, err
+		}
+
+		if err := c.retry.wait(ctx, attempt); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// roundTrip performs a single attempt of a daemon request
+func (c *Client) roundTrip(ctx context.Context, httpClient *http.Client, url, endpoint, method string, bodyBytes []byte) (*http.Response, error) {
+	var req *http.Request
+	var err error
 
 	if method == "POST" {
-		resp, err = http.Post(
-			fmt.Sprintf("http://127.0.0.1:%d/%s", port(), endpoint),
-			"application/json",
-			bytes.NewBuffer(bodyBytes),
+		req, err = http.NewRequestWithContext(
+			ctx,
+			method,
+			url,
+			bytes.NewReader(bodyBytes),
 		)
-	} else if method == "GET" {
-		resp, err = http.Get(
-			fmt.Sprintf("http://127.0.0.1:%d/%s", port(), endpoint),
+		if err == nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+	} else {
+		req, err = http.NewRequestWithContext(
+			ctx,
+			method,
+			url,
+			nil,
 		)
 	}
 	if err != nil {
+		return nil, fmt.Errorf("Error building daemon request: %w", err)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		var opErr *net.OpError
+		if ctx.Err() == nil && errors.As(err, &opErr) && opErr.Op == "dial" {
+			err = &unavailableError{err}
+		}
 		return nil, fmt.Errorf("Error in daemon request: %w", err)
 	}
 
 	if resp.StatusCode >= http.StatusBadRequest {
-		// TODO: can this be a more specific type?
-		var responseBody interface{}
-		err = json.NewDecoder(resp.Body).Decode(&responseBody)
+		defer resp.Body.Close()
+		responseBody, err := ioutil.ReadAll(resp.Body)
 		if err != nil {
-			return nil, fmt.Errorf("Status code %d, with JSON decode error %w on body", resp.StatusCode, err)
+			return nil, fmt.Errorf("Status code %d, with read error %w on body", resp.StatusCode, err)
 		}
-		return nil, fmt.Errorf("Status code %d with JSON error %v", resp.StatusCode, responseBody)
+		return nil, NewError(resp.StatusCode, endpoint, method, responseBody)
 	}
 
 	return resp, nil
 }
 
-func SimpleRequest(endpoint string, body interface{}, method string) error {
-	_, err := daemonRequest(endpoint, body, method)
-	return err
+// simpleRequest sends a request to the daemon and discards the response body.
+func (c *Client) simpleRequest(ctx context.Context, req *Request) error {
+	resp, err := c.daemonRequest(ctx, req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
 }
 
-func SyncRequest(endpoint string, body interface{}, method string) (interface{}, error) {
-	resp, err := daemonRequest(endpoint, body, method)
+// syncRequest sends a request to the daemon and returns the "value" field
+// of the JSON response.
+func (c *Client) syncRequest(ctx context.Context, req *Request) (interface{}, error) {
+	resp, err := c.daemonRequest(ctx, req)
 	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
 
 	var responseBody struct {
 		Value interface{} `json:"value"`
@@ -85,11 +117,17 @@ func SyncRequest(endpoint string, body interface{}, method string) (interface{},
 	return responseBody.Value, nil
 }
 
-func AsyncRequest(endpoint string, body interface{}, method string) (map[string]interface{}, error) {
-	resp, err := daemonRequest(endpoint, body, method)
+// asyncRequest sends a request to the daemon, which answers with the name of
+// a reply file, and returns the decoded contents of that file.
+//
+// Cancelling ctx aborts both the HTTP round trip and the wait for the
+// reply file.
+func (c *Client) asyncRequest(ctx context.Context, req *Request) (map[string]interface{}, error) {
+	resp, err := c.daemonRequest(ctx, req)
 	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
 
 	var responseBody struct {
 		Filename string `json:"replyFilename"`
@@ -100,16 +138,63 @@ func AsyncRequest(endpoint string, body interface{}, method string) (map[string]
 		return nil, fmt.Errorf("Error decoding daemon response %w", err)
 	}
 
-	bytes, err := ioutil.ReadFile(responseBody.Filename)
+	reply, err := c.readReplyFile(ctx, responseBody.Filename)
 	if err != nil {
 		return nil, fmt.Errorf("Error reading daemon response %w", err)
 	}
 
+	// Numbers are kept as json.Number, so that answers are decoded exactly
 	responseMap := make(map[string]interface{})
-	err = json.Unmarshal(bytes, &responseMap)
+	decoder := json.NewDecoder(bytes.NewReader(reply))
+	decoder.UseNumber()
+	err = decoder.Decode(&responseMap)
 	if err != nil {
 		return nil, fmt.Errorf("Error unmarshalling daemon response %w", err)
 	}
 
 	return responseMap, nil
 }
+
+// Ping checks whether the daemon can be reached.
+//
+// Any HTTP response counts as success, since the daemon has no dedicated
+// health endpoint; errors wrap ErrUnavailable. When requests are served by
+// the fallback, it is asked for the capabilities instead, and any answer
+// other than an error from outside the protocol counts as success.
+func (c *Client) Ping(ctx context.Context) error {
+	if c == nil {
+		c = defaultClient
+	}
+
+	if c.useFallback() {
+		_, err := c.fallback(ctx, &Request{Kind: Sync, Endpoint: "capabilities", Method: "GET"})
+		var daemonErr *Error
+		if err != nil && !errors.As(err, &daemonErr) {
+			return &unavailableError{err}
+		}
+		return nil
+	}
+
+	resp, err := c.daemonRequest(ctx, &Request{Kind: Simple, Method: "GET"})
+	if err != nil {
+		var daemonErr *Error
+		if errors.As(err, &daemonErr) {
+			return nil
+		}
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// statusCode extracts the HTTP status code from the outcome of a round trip,
+// or 0 if the daemon never responded.
+func statusCode(resp *http.Response, err error) int {
+	if resp != nil {
+		return resp.StatusCode
+	}
+	var daemonErr *Error
+	if errors.As(err, &daemonErr) {
+		return daemonErr.StatusCode
+	}
+	return 0
+}
@@ -0,0 +1,76 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// WithDebug logs every daemon exchange to w, with secret values masked.
+//
+// Debug logging is also enabled, writing to stderr, when the SDK_DEBUG
+// environment variable is set to a true value such as "1" or "true".
+func WithDebug(w io.Writer) Option {
+	return func(c *Client) {
+		c.debug = w
+	}
+}
+
+// debugFromEnv returns the debug writer requested by SDK_DEBUG, if any
+func debugFromEnv() io.Writer {
+	enabled, err := strconv.ParseBool(os.Getenv("SDK_DEBUG"))
+	if err != nil || !enabled {
+		return nil
+	}
+	return os.Stderr
+}
+
+// debugLogger writes one line per exchange, serialized across goroutines
+type debugLogger struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *debugLogger) printf(format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintf(l.w, "[ctoai] "+format+"\n", args...)
+}
+
+func jsonString(v interface{}) string {
+	bytes, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("<unencodable: %v>", err)
+	}
+	return string(bytes)
+}
+
+// middleware logs the request and its outcome
+func (l *debugLogger) middleware(next Handler) Handler {
+	return func(ctx context.Context, req *Request) (interface{}, error) {
+		body, _ := Redact(req, nil)
+		l.printf("%s %s (%s) request %s", req.Method, req.Endpoint, req.Kind, jsonString(body))
+
+		start := time.Now()
+		value, err := next(ctx, req)
+		elapsed := time.Since(start)
+
+		if err != nil {
+			l.printf("%s %s status %d after %v error %v", req.Method, req.Endpoint, req.StatusCode, elapsed, err)
+			return value, err
+		}
+
+		_, redacted := Redact(req, value)
+		if req.Kind == Simple {
+			l.printf("%s %s status %d after %v", req.Method, req.Endpoint, req.StatusCode, elapsed)
+		} else {
+			l.printf("%s %s status %d after %v response %s", req.Method, req.Endpoint, req.StatusCode, elapsed, jsonString(redacted))
+		}
+		return value, err
+	}
+}
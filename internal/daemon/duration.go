@@ -0,0 +1,80 @@
+package daemon
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// durationPart matches a number followed by a unit, as in "90 minutes"
+var durationPart = regexp.MustCompile(`(\d+(?:\.\d*)?|\.\d+)\s*([a-zµμ]+)`)
+
+// durationUnits maps the units accepted by ParseDuration to their length
+var durationUnits = map[string]time.Duration{
+	"ns": time.Nanosecond, "nanosecond": time.Nanosecond, "nanoseconds": time.Nanosecond,
+	"us": time.Microsecond, "µs": time.Microsecond, "μs": time.Microsecond, "microsecond": time.Microsecond, "microseconds": time.Microsecond,
+	"ms": time.Millisecond, "millisecond": time.Millisecond, "milliseconds": time.Millisecond,
+	"s": time.Second, "sec": time.Second, "secs": time.Second, "second": time.Second, "seconds": time.Second,
+	"m": time.Minute, "min": time.Minute, "mins": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+	"h": time.Hour, "hr": time.Hour, "hrs": time.Hour, "hour": time.Hour, "hours": time.Hour,
+	"d": 24 * time.Hour, "day": 24 * time.Hour, "days": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour, "wk": 7 * 24 * time.Hour, "wks": 7 * 24 * time.Hour, "week": 7 * 24 * time.Hour, "weeks": 7 * 24 * time.Hour,
+}
+
+// ParseDuration parses a duration written in the format of
+// time.ParseDuration, such as "1h30m", or in words, such as "90 minutes"
+// or "2 days, 3 hours and 30 minutes". Days are 24 hours and weeks are 7
+// days long.
+func ParseDuration(text string) (time.Duration, error) {
+	text = strings.TrimSpace(text)
+	if d, err := time.ParseDuration(text); err == nil {
+		return d, nil
+	}
+
+	invalid := fmt.Errorf("%q is not a duration such as 1h30m or 90 minutes", text)
+	lower := strings.ToLower(text)
+	matches := durationPart.FindAllStringSubmatchIndex(lower, -1)
+	if len(matches) == 0 {
+		return 0, invalid
+	}
+
+	var total float64
+	last := 0
+	for _, m := range matches {
+		if !durationSeparator(lower[last:m[0]]) {
+			return 0, invalid
+		}
+		unit, ok := durationUnits[lower[m[4]:m[5]]]
+		if !ok {
+			return 0, fmt.Errorf("%q has unknown unit %q", text, lower[m[4]:m[5]])
+		}
+		n, err := strconv.ParseFloat(lower[m[2]:m[3]], 64)
+		if err != nil {
+			return 0, invalid
+		}
+		total += n * float64(unit)
+		last = m[1]
+	}
+	if !durationSeparator(lower[last:]) {
+		return 0, invalid
+	}
+
+	if total >= math.MaxInt64 {
+		return 0, fmt.Errorf("%q is too long a duration", text)
+	}
+	return time.Duration(math.Round(total)), nil
+}
+
+// durationSeparator reports whether text only separates the parts of a
+// duration, with spaces, commas and "and"
+func durationSeparator(text string) bool {
+	for _, word := range strings.Fields(strings.Replace(text, ",", " ", -1)) {
+		if word != "and" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,120 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrUnavailable is returned when there is no daemon to talk to, either
+// because SDK_SPEAK_PORT is missing or malformed or because nothing is
+// listening on it.
+var ErrUnavailable = errors.New("The CTO.ai Ops SDK requires a daemon process to be running; this does not appear to be the case.")
+
+// unavailableError wraps the underlying cause of ErrUnavailable so that
+// both can be matched with errors.Is and errors.As.
+type unavailableError struct {
+	err error
+}
+
+func (e *unavailableError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrUnavailable, e.err)
+}
+
+func (e *unavailableError) Is(target error) bool {
+	return target == ErrUnavailable
+}
+
+func (e *unavailableError) Unwrap() error {
+	return e.err
+}
+
+// Error codes sent by the daemon in error payloads
+const (
+	CodeNotFound      = "not_found"
+	CodeUserCancelled = "user_cancelled"
+	CodeValidation    = "validation"
+)
+
+// statusClientClosedRequest is the non-standard status the daemon uses when
+// the user abandons a prompt.
+const statusClientClosedRequest = 499
+
+// Error is returned when the daemon responds with an error status code
+type Error struct {
+	// StatusCode is the HTTP status code of the response
+	StatusCode int
+	// Endpoint is the daemon endpoint that was requested, e.g. "prompt"
+	Endpoint string
+	// Method is the HTTP method of the request
+	Method string
+	// Message is the human-readable error message sent by the daemon, if any
+	Message string
+	// Code is the machine-readable error code sent by the daemon, if any
+	Code string
+	// Payload is the decoded JSON body of the response, or nil if the body
+	// was not valid JSON
+	Payload interface{}
+	// Body is the raw body of the response
+	Body []byte
+}
+
+// NewError builds an Error, extracting the message and code from the
+// daemon's JSON error payload if present.
+//
+// The daemon sends either {"message": ..., "code": ...} or
+// {"error": ...}; anything else is kept only as the payload.
+func NewError(statusCode int, endpoint, method string, body []byte) *Error {
+	e := &Error{
+		StatusCode: statusCode,
+		Endpoint:   endpoint,
+		Method:     method,
+		Body:       body,
+	}
+
+	if err := json.Unmarshal(body, &e.Payload); err != nil {
+		e.Payload = nil
+		return e
+	}
+
+	if object, ok := e.Payload.(map[string]interface{}); ok {
+		if message, ok := object["message"].(string); ok {
+			e.Message = message
+		} else if message, ok := object["error"].(string); ok {
+			e.Message = message
+		}
+		if code, ok := object["code"].(string); ok {
+			e.Code = code
+		}
+	}
+
+	return e
+}
+
+func (e *Error) Error() string {
+	message := e.Message
+	if message == "" {
+		message = string(e.Body)
+	}
+	if e.Code != "" {
+		message = fmt.Sprintf("%s (%s)", message, e.Code)
+	}
+	return fmt.Sprintf("Status code %d from daemon %s %s: %s", e.StatusCode, e.Method, e.Endpoint, message)
+}
+
+// NotFound reports whether the daemon could not find the requested item
+func (e *Error) NotFound() bool {
+	return e.Code == CodeNotFound || (e.Code == "" && e.StatusCode == http.StatusNotFound)
+}
+
+// UserCancelled reports whether the user cancelled the request, e.g. by
+// dismissing a prompt
+func (e *Error) UserCancelled() bool {
+	return e.Code == CodeUserCancelled || (e.Code == "" && e.StatusCode == statusClientClosedRequest)
+}
+
+// Validation reports whether the daemon rejected the request as invalid
+func (e *Error) Validation() bool {
+	return e.Code == CodeValidation || (e.Code == "" && (e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusUnprocessableEntity))
+}
@@ -0,0 +1,173 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+)
+
+// RequestKind identifies how the daemon answers a request
+type RequestKind int
+
+const (
+	// Simple requests have no response value
+	Simple RequestKind = iota
+	// Sync requests respond with a value directly
+	Sync
+	// Async requests respond with a reply file holding a map of values
+	Async
+)
+
+func (k RequestKind) String() string {
+	switch k {
+	case Simple:
+		return "simple"
+	case Sync:
+		return "sync"
+	case Async:
+		return "async"
+	}
+	return fmt.Sprintf("RequestKind(%d)", int(k))
+}
+
+// Request describes a single call to the daemon
+type Request struct {
+	Kind     RequestKind
+	Endpoint string
+	Method   string
+	Body     interface{}
+
+	// StatusCode is the HTTP status code of the daemon's response, set by
+	// the transport once the request has been sent. It is 0 if the daemon
+	// never responded.
+	StatusCode int
+}
+
+// Handler performs a daemon request.
+//
+// The response is nil for Simple requests, the decoded "value" field for
+// Sync requests and the decoded reply file, a map[string]interface{}, for
+// Async requests.
+type Handler func(ctx context.Context, req *Request) (interface{}, error)
+
+// Middleware wraps a Handler to observe or alter daemon requests
+type Middleware func(next Handler) Handler
+
+// Use appends middleware to the chain run for every request. The first
+// middleware registered is the outermost.
+func (c *Client) Use(middleware ...Middleware) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.middleware = append(c.middleware, middleware...)
+}
+
+// handler builds the middleware chain around the HTTP transport. Debug
+// logging is innermost, so that it shows what is actually exchanged with
+// the daemon.
+func (c *Client) handler() Handler {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	h := Handler(c.send)
+	if c.debugLog != nil {
+		h = c.debugLog.middleware(h)
+	}
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		h = c.middleware[i](h)
+	}
+	return h
+}
+
+// send is the innermost Handler, which talks to the daemon over HTTP or
+// hands the request to the fallback
+func (c *Client) send(ctx context.Context, req *Request) (interface{}, error) {
+	if c.useFallback() {
+		return c.fallback(ctx, req)
+	}
+
+	switch req.Kind {
+	case Simple:
+		return nil, c.simpleRequest(ctx, req)
+	case Sync:
+		return c.syncRequest(ctx, req)
+	case Async:
+		value, err := c.asyncRequest(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return value, nil
+	}
+	return nil, fmt.Errorf("Unknown request kind %v", req.Kind)
+}
+
+func (c *Client) do(ctx context.Context, kind RequestKind, endpoint string, body interface{}, method string) (interface{}, error) {
+	if c == nil {
+		c = defaultClient
+	}
+	if err := c.checkEndpoint(endpoint); err != nil {
+		return nil, err
+	}
+	return c.handler()(ctx, &Request{
+		Kind:     kind,
+		Endpoint: endpoint,
+		Method:   method,
+		Body:     body,
+	})
+}
+
+// checkEndpoint fails fast if the daemon is already known not to serve
+// endpoint
+func (c *Client) checkEndpoint(endpoint string) error {
+	if caps, ok := c.cachedCapabilities(); ok {
+		return caps.RequireEndpoint(endpoint)
+	}
+	return nil
+}
+
+// SimpleRequest sends a request to the daemon and discards the response body.
+func (c *Client) SimpleRequest(ctx context.Context, endpoint string, body interface{}, method string) error {
+	_, err := c.do(ctx, Simple, endpoint, body, method)
+	return err
+}
+
+// SyncRequest sends a request to the daemon and returns the "value" field
+// of the JSON response.
+func (c *Client) SyncRequest(ctx context.Context, endpoint string, body interface{}, method string) (interface{}, error) {
+	return c.do(ctx, Sync, endpoint, body, method)
+}
+
+// PollRequest is like SyncRequest but bypasses the middleware chain and
+// debug logging. It is meant for requests repeated many times a second,
+// such as polling for the query typed into an autocomplete prompt, which
+// would otherwise flood logs and traces.
+func (c *Client) PollRequest(ctx context.Context, endpoint string, body interface{}, method string) (interface{}, error) {
+	if c == nil {
+		c = defaultClient
+	}
+	if err := c.checkEndpoint(endpoint); err != nil {
+		return nil, err
+	}
+	return c.send(ctx, &Request{
+		Kind:     Sync,
+		Endpoint: endpoint,
+		Method:   method,
+		Body:     body,
+	})
+}
+
+// AsyncRequest sends a request to the daemon, which answers with the name of
+// a reply file, and returns the decoded contents of that file.
+//
+// Cancelling ctx aborts both the HTTP round trip and the wait for the
+// reply file.
+func (c *Client) AsyncRequest(ctx context.Context, endpoint string, body interface{}, method string) (map[string]interface{}, error) {
+	value, err := c.do(ctx, Async, endpoint, body, method)
+	if err != nil || value == nil {
+		return nil, err
+	}
+
+	responseMap, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Middleware returned non-map response %v for async request", value)
+	}
+	return responseMap, nil
+}
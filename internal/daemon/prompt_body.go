@@ -1,7 +1,16 @@
 package daemon
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 // PromptEnvelope is the common fields for all prompt type JSON bodies
@@ -10,15 +19,88 @@ type PromptEnvelope struct {
 	PromptType string `json:"type"`
 	Message    string `json:"message"`
 	Flag       string `json:"flag,omitempty"`
+
+	// Validate checks the answer on the SDK side; it is not sent
+	Validate func(answer interface{}) error `json:"-"`
+}
+
+// Check runs the validation of the prompt on its answer, if it has one
+func (e PromptEnvelope) Check(answer interface{}) error {
+	if e.Validate == nil {
+		return nil
+	}
+	return e.Validate(answer)
 }
 
 // InputPromptBody is the JSON body for an input prompt
 type InputPromptBody struct {
 	PromptEnvelope
+	InputConstraints
 	Default    string `json:"default,omitempty"`
 	AllowEmpty bool   `json:"allowEmpty"`
 }
 
+// Check checks the answer against the constraints of the prompt, then runs
+// its validation
+func (b InputPromptBody) Check(answer interface{}) error {
+	if s, _ := answer.(string); s != "" || !b.AllowEmpty {
+		if err := b.CheckAnswer(s); err != nil {
+			return err
+		}
+	}
+	return b.PromptEnvelope.Check(answer)
+}
+
+// InputConstraints are the declarative constraints on the answer to an
+// input prompt, enforced by the daemon and checked again by the SDK
+type InputConstraints struct {
+	// Pattern is a regular expression the whole answer must match
+	Pattern string `json:"pattern,omitempty"`
+	// MinLength and MaxLength bound the answer's length in characters; 0
+	// means unbounded
+	MinLength int `json:"minLength,omitempty"`
+	MaxLength int `json:"maxLength,omitempty"`
+	// ErrorMessage replaces the message shown for a rejected answer
+	ErrorMessage string `json:"errorMessage,omitempty"`
+}
+
+// Compile checks that the pattern is a valid regular expression
+func (c InputConstraints) Compile() (*regexp.Regexp, error) {
+	re, err := regexp.Compile(`^(?:` + c.Pattern + `)$`)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid input pattern %q: %w", c.Pattern, err)
+	}
+	return re, nil
+}
+
+// CheckAnswer returns an error describing how answer breaks the
+// constraints, or nil if it does not
+func (c InputConstraints) CheckAnswer(answer string) error {
+	var problem string
+	length := utf8.RuneCountInString(answer)
+	switch {
+	case c.MinLength > 0 && length < c.MinLength:
+		problem = fmt.Sprintf("Answer must be at least %d characters long", c.MinLength)
+	case c.MaxLength > 0 && length > c.MaxLength:
+		problem = fmt.Sprintf("Answer must be at most %d characters long", c.MaxLength)
+	case c.Pattern != "":
+		re, err := c.Compile()
+		if err != nil {
+			return err
+		}
+		if !re.MatchString(answer) {
+			problem = fmt.Sprintf("Answer must match the pattern %s", c.Pattern)
+		}
+	}
+	if problem == "" {
+		return nil
+	}
+	if c.ErrorMessage != "" {
+		problem = c.ErrorMessage
+	}
+	return errors.New(problem)
+}
+
 // NumberPromptBody is the JSON body for a number prompt
 type NumberPromptBody struct {
 	PromptEnvelope
@@ -54,6 +136,69 @@ func (n NumberPromptBody) MarshalJSON() ([]byte, error) {
 	return json.Marshal(output)
 }
 
+// NumericPromptBody is the JSON body for a number prompt with exact
+// bounds, used for fractional and 64-bit answers. Unset bounds are empty.
+type NumericPromptBody struct {
+	PromptEnvelope
+	// Float makes the prompt accept fractional answers
+	Float     bool        `json:"float,omitempty"`
+	Default   json.Number `json:"default,omitempty"`
+	Minimum   json.Number `json:"minimum,omitempty"`
+	Maximum   json.Number `json:"maximum,omitempty"`
+	Step      json.Number `json:"step,omitempty"`
+	Precision *int        `json:"precision,omitempty"`
+}
+
+// Check checks the answer against the bounds, step and precision of the
+// prompt, then runs its validation
+func (b NumericPromptBody) Check(answer interface{}) error {
+	value, ok := ratOf(answer)
+	if !ok {
+		return b.PromptEnvelope.Check(answer)
+	}
+
+	base := new(big.Rat)
+	if minimum, ok := new(big.Rat).SetString(string(b.Minimum)); ok {
+		if value.Cmp(minimum) < 0 {
+			return fmt.Errorf("Answer must be at least %s", b.Minimum)
+		}
+		base = minimum
+	}
+	if maximum, ok := new(big.Rat).SetString(string(b.Maximum)); ok && value.Cmp(maximum) > 0 {
+		return fmt.Errorf("Answer must be at most %s", b.Maximum)
+	}
+	if step, ok := new(big.Rat).SetString(string(b.Step)); ok && step.Sign() > 0 {
+		steps := new(big.Rat).Sub(value, base)
+		if !steps.Quo(steps, step).IsInt() {
+			if base.Sign() == 0 {
+				return fmt.Errorf("Answer must be a multiple of %s", b.Step)
+			}
+			return fmt.Errorf("Answer must be %s plus a multiple of %s", b.Minimum, b.Step)
+		}
+	}
+	if f, ok := answer.(float64); ok && b.Precision != nil {
+		text := strconv.FormatFloat(f, 'f', -1, 64)
+		if i := strings.IndexByte(text, '.'); i >= 0 && len(text)-i-1 > *b.Precision {
+			return fmt.Errorf("Answer must have at most %d decimal places", *b.Precision)
+		}
+	}
+	return b.PromptEnvelope.Check(answer)
+}
+
+// ratOf converts a numeric answer to an exact rational, using the shortest
+// decimal form of floats so that e.g. 0.3 is a multiple of 0.1
+func ratOf(answer interface{}) (*big.Rat, bool) {
+	switch v := answer.(type) {
+	case float64:
+		return new(big.Rat).SetString(strconv.FormatFloat(v, 'g', -1, 64))
+	case int64:
+		return new(big.Rat).SetInt64(v), true
+	case uint64:
+		return new(big.Rat).SetInt(new(big.Int).SetUint64(v)), true
+	}
+	return nil, false
+}
+
 // SecretPromptBody is the JSON body for a secret prompt
 type SecretPromptBody = PromptEnvelope
 
@@ -69,10 +214,20 @@ type ConfirmPromptBody struct {
 	Default bool `json:"default"`
 }
 
+// LabeledChoice is a choice of a list or checkbox prompt that is shown with
+// a label and description, and answered with its value
+type LabeledChoice struct {
+	Value       string `json:"value"`
+	Label       string `json:"label"`
+	Description string `json:"description,omitempty"`
+	Disabled    bool   `json:"disabled,omitempty"`
+}
+
 // ListPromptBody is the JSON body for a list or autocomplete prompt
 type ListPromptBody struct {
 	PromptEnvelope
 	Choices        []string
+	Labeled        []LabeledChoice
 	DefaultSet     bool
 	DefaultIndex   int
 	DefaultValue   string
@@ -86,6 +241,9 @@ func (n ListPromptBody) MarshalJSON() ([]byte, error) {
 	output["type"] = n.PromptType
 	output["message"] = n.Message
 	output["choices"] = n.Choices
+	if n.Labeled != nil {
+		output["choices"] = n.Labeled
+	}
 
 	if n.Flag != "" {
 		output["flag"] = n.Flag
@@ -105,7 +263,9 @@ func (n ListPromptBody) MarshalJSON() ([]byte, error) {
 // CheckboxPromptBody is the JSON body for a checkbox prompt
 type CheckboxPromptBody struct {
 	PromptEnvelope
+	SelectionConstraints
 	Choices        []string
+	Labeled        []LabeledChoice
 	DefaultSet     bool
 	DefaultIndex   []int
 	DefaultValue   []string
@@ -119,10 +279,19 @@ func (n CheckboxPromptBody) MarshalJSON() ([]byte, error) {
 	output["type"] = n.PromptType
 	output["message"] = n.Message
 	output["choices"] = n.Choices
+	if n.Labeled != nil {
+		output["choices"] = n.Labeled
+	}
 
 	if n.Flag != "" {
 		output["flag"] = n.Flag
 	}
+	if n.MinSelected > 0 {
+		output["minSelected"] = n.MinSelected
+	}
+	if n.MaxSelected > 0 {
+		output["maxSelected"] = n.MaxSelected
+	}
 
 	if n.DefaultSet {
 		if n.DefaultIsValue {
@@ -135,6 +304,77 @@ func (n CheckboxPromptBody) MarshalJSON() ([]byte, error) {
 	return json.Marshal(output)
 }
 
+// Check checks the number of selected choices against the constraints of
+// the prompt, then runs its validation
+func (n CheckboxPromptBody) Check(answer interface{}) error {
+	if selected, ok := answer.([]string); ok {
+		if err := n.CheckSelected(len(selected)); err != nil {
+			return err
+		}
+	}
+	return n.PromptEnvelope.Check(answer)
+}
+
+// SelectionConstraints bound the number of choices selected in a checkbox
+// prompt, enforced by the daemon and checked again by the SDK
+type SelectionConstraints struct {
+	// MinSelected and MaxSelected bound the number of selected choices; 0
+	// means unbounded
+	MinSelected int
+	MaxSelected int
+}
+
+// CheckSelected returns an error if selecting count choices breaks the
+// constraints, or nil if it does not
+func (c SelectionConstraints) CheckSelected(count int) error {
+	switch {
+	case c.MinSelected > 0 && count < c.MinSelected:
+		return fmt.Errorf("Select at least %s", choiceCount(c.MinSelected))
+	case c.MaxSelected > 0 && count > c.MaxSelected:
+		return fmt.Errorf("Select at most %s", choiceCount(c.MaxSelected))
+	}
+	return nil
+}
+
+func choiceCount(n int) string {
+	if n == 1 {
+		return "one choice"
+	}
+	return fmt.Sprintf("%d choices", n)
+}
+
+// AutocompletePromptBody is the JSON body for an autocomplete prompt whose
+// choices are served by the SDK as the user types. Choices holds the
+// choices for an empty query.
+//
+// While the prompt is open, the SDK polls the autocomplete/query endpoint
+// for what the user has typed and answers each new query by posting an
+// AutocompleteChoicesBody to the autocomplete/choices endpoint.
+type AutocompletePromptBody struct {
+	PromptEnvelope
+	Dynamic bool            `json:"dynamic"`
+	Choices []LabeledChoice `json:"choices"`
+
+	// Serve serves the daemon's queries while the prompt is open, until
+	// ctx is done; it is not sent
+	Serve func(ctx context.Context) error `json:"-"`
+}
+
+// AutocompleteQueryBody is the JSON body for an autocomplete/query request
+type AutocompleteQueryBody struct {
+	Name string `json:"name"`
+}
+
+// AutocompleteChoicesBody is the JSON body for an autocomplete/choices
+// request, holding the choices matching a query. Error describes why no
+// choices could be found, if it is set.
+type AutocompleteChoicesBody struct {
+	Name    string          `json:"name"`
+	Query   string          `json:"query"`
+	Choices []LabeledChoice `json:"choices"`
+	Error   string          `json:"error,omitempty"`
+}
+
 // EditorPromptBody is the JSON body for a editor prompt
 type EditorPromptBody struct {
 	PromptEnvelope
@@ -149,3 +389,43 @@ type DatetimePromptBody struct {
 	Maximum string `json:"maximum,omitempty"`
 	Minimum string `json:"minimum,omitempty"`
 }
+
+// DurationPromptBody is the JSON body for a duration prompt. The default
+// and bounds are in the format of time.Duration.String, e.g. "1h30m0s";
+// unset ones are empty.
+type DurationPromptBody struct {
+	PromptEnvelope
+	Default string `json:"default,omitempty"`
+	Minimum string `json:"minimum,omitempty"`
+	Maximum string `json:"maximum,omitempty"`
+}
+
+// Check checks that the answer is a duration within the bounds of the
+// prompt, then runs its validation. Without a minimum, negative durations
+// are rejected. An answer that could not be parsed is given as the text
+// typed by the user.
+func (b DurationPromptBody) Check(answer interface{}) error {
+	if text, ok := answer.(string); ok {
+		if _, err := ParseDuration(text); err != nil {
+			return err
+		}
+	}
+	if d, ok := answer.(time.Duration); ok {
+		minimum, err := ParseDuration(b.Minimum)
+		if err != nil && d < 0 {
+			return errors.New("Answer must not be negative")
+		}
+		if err == nil && d < minimum {
+			return fmt.Errorf("Answer must be at least %s", minimum)
+		}
+		if maximum, err := ParseDuration(b.Maximum); err == nil && d > maximum {
+			return fmt.Errorf("Answer must be at most %s", maximum)
+		}
+	}
+	return b.PromptEnvelope.Check(answer)
+}
+
+// PromptsBody is the JSON body for several prompts presented together
+type PromptsBody struct {
+	Prompts []interface{} `json:"prompts"`
+}
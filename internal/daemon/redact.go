@@ -0,0 +1,89 @@
+package daemon
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Mask replaces sensitive values in redacted requests and responses
+const Mask = "********"
+
+// sensitivePromptTypes are the prompt types whose answers are secret
+var sensitivePromptTypes = map[string]bool{
+	"secret":   true,
+	"password": true,
+}
+
+// toJSONValue converts v to its generic JSON representation, so that it can
+// be inspected and masked without knowing its Go type. Numbers are kept as
+// json.Number so that integers beyond float64 precision survive.
+func toJSONValue(v interface{}) interface{} {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil
+	}
+	return value
+}
+
+// Redact returns copies of the request body and response value of req with
+// secret values masked, as generic JSON values.
+//
+// The value of secret/set requests, the response of secret/get requests and
+// the answers to secret and password prompts are masked.
+func Redact(req *Request, response interface{}) (body interface{}, value interface{}) {
+	body = toJSONValue(req.Body)
+	value = toJSONValue(response)
+
+	switch req.Endpoint {
+	case "secret/set":
+		if object, ok := body.(map[string]interface{}); ok {
+			if _, ok := object["value"]; ok {
+				object["value"] = Mask
+			}
+		}
+	case "secret/get":
+		value = maskValues(value)
+	case "prompt":
+		if object, ok := body.(map[string]interface{}); ok {
+			if promptType, _ := object["type"].(string); sensitivePromptTypes[promptType] {
+				value = maskValues(value)
+			}
+		}
+	case "prompts":
+		object, _ := body.(map[string]interface{})
+		prompts, _ := object["prompts"].([]interface{})
+		answers, _ := value.(map[string]interface{})
+		for _, prompt := range prompts {
+			definition, _ := prompt.(map[string]interface{})
+			promptType, _ := definition["type"].(string)
+			name, _ := definition["name"].(string)
+			if _, ok := answers[name]; ok && sensitivePromptTypes[promptType] {
+				answers[name] = Mask
+			}
+		}
+	}
+
+	return body, value
+}
+
+// maskValues masks every value of a JSON object, or the whole value if it
+// is not an object
+func maskValues(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return Mask
+	}
+	for k := range object {
+		object[k] = Mask
+	}
+	return object
+}
@@ -0,0 +1,196 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	defaultReplyTimeout = 30 * time.Second
+	defaultReplyMaxSize = 10 << 20
+	replyPollInterval   = 10 * time.Millisecond
+)
+
+// ErrUnsafeReplyFile is returned when the reply file named by the daemon is
+// outside the reply directory, is not a regular file, is owned by another
+// user or can be written by other users.
+var ErrUnsafeReplyFile = errors.New("unsafe daemon reply file")
+
+// WithReplyDir sets the directory the daemon writes reply files into.
+// Reply files elsewhere are rejected. Defaults to os.TempDir().
+func WithReplyDir(dir string) Option {
+	return func(c *Client) {
+		c.replyDir = dir
+	}
+}
+
+// WithReplyTimeout sets how long to wait for the daemon to finish writing a
+// reply file after it has responded. Defaults to 30 seconds.
+func WithReplyTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.replyTimeout = timeout
+	}
+}
+
+// WithReplyMaxSize sets the largest reply file that will be read, in bytes.
+// Defaults to 10 MiB.
+func WithReplyMaxSize(size int64) Option {
+	return func(c *Client) {
+		c.replyMaxSize = size
+	}
+}
+
+// checkReplyPath verifies that filename is inside the reply directory
+func (c *Client) checkReplyPath(filename string) (string, error) {
+	dir := c.replyDir
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	// The directory may itself be a symlink, e.g. /tmp on macOS
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+
+	if !filepath.IsAbs(filename) {
+		return "", fmt.Errorf("%w: %s is not an absolute path", ErrUnsafeReplyFile, filename)
+	}
+	filename = filepath.Clean(filename)
+	if parent, err := filepath.EvalSymlinks(filepath.Dir(filename)); err == nil {
+		filename = filepath.Join(parent, filepath.Base(filename))
+	}
+
+	rel, err := filepath.Rel(dir, filename)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %s is outside %s", ErrUnsafeReplyFile, filename, dir)
+	}
+	return filename, nil
+}
+
+// readReplyFile waits for the daemon to finish writing the reply file,
+// reads it and removes it.
+//
+// The daemon either renames the file into place once it is complete or
+// holds a "<filename>.lock" file while writing; in both cases the contents
+// must also be valid JSON before they are accepted. The wait ends when ctx
+// is done or the reply timeout expires.
+func (c *Client) readReplyFile(ctx context.Context, filename string) ([]byte, error) {
+	filename, err := c.checkReplyPath(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := c.replyTimeout
+	if timeout <= 0 {
+		timeout = defaultReplyTimeout
+	}
+	maxSize := c.replyMaxSize
+	if maxSize <= 0 {
+		maxSize = defaultReplyMaxSize
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(replyPollInterval)
+	defer ticker.Stop()
+
+	lastErr := fmt.Errorf("reply file %s was not written", filename)
+	for {
+		bytes, ready, err := tryReadReplyFile(filename, maxSize)
+		if err != nil {
+			return nil, err
+		}
+		if ready {
+			return bytes, nil
+		}
+		if bytes != nil {
+			lastErr = fmt.Errorf("reply file %s is incomplete", filename)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%v: %w", lastErr, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// tryReadReplyFile makes a single attempt at reading the reply file. It
+// reports ready once the file is unlocked and holds valid JSON, and then
+// zeroes and removes the file; a non-nil error means waiting any longer
+// will not help.
+//
+// The file is opened once without following symlinks, and every check,
+// read and write goes through that descriptor, so the file cannot be
+// swapped between checking it and reading it.
+func tryReadReplyFile(filename string, maxSize int64) ([]byte, bool, error) {
+	if _, err := os.Lstat(filename + ".lock"); err == nil {
+		return nil, false, nil
+	}
+
+	file, err := openReplyFile(filename, os.O_RDWR)
+	if os.IsPermission(err) {
+		// A reply the daemon made read-only can still be read, just not
+		// zeroed before removal
+		file, err = openReplyFile(filename, os.O_RDONLY)
+	}
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		if info, lerr := os.Lstat(filename); lerr == nil && !info.Mode().IsRegular() {
+			return nil, false, fmt.Errorf("%w: %s is not a regular file", ErrUnsafeReplyFile, filename)
+		}
+		return nil, false, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, false, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, false, fmt.Errorf("%w: %s is not a regular file", ErrUnsafeReplyFile, filename)
+	}
+	if !ownedByCurrentUser(info) {
+		return nil, false, fmt.Errorf("%w: %s is owned by another user", ErrUnsafeReplyFile, filename)
+	}
+	if info.Mode().Perm()&0002 != 0 {
+		return nil, false, fmt.Errorf("%w: %s is world-writable", ErrUnsafeReplyFile, filename)
+	}
+	if info.Size() > maxSize {
+		return nil, false, fmt.Errorf("reply file %s is larger than %d bytes", filename, maxSize)
+	}
+
+	bytes, err := ioutil.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil {
+		return nil, false, err
+	}
+	if int64(len(bytes)) > maxSize {
+		return nil, false, fmt.Errorf("reply file %s is larger than %d bytes", filename, maxSize)
+	}
+	if !json.Valid(bytes) {
+		return bytes, false, nil
+	}
+
+	removeReplyFile(file, filename, int64(len(bytes)))
+	return bytes, true, nil
+}
+
+// removeReplyFile overwrites the open reply file with zeros before removing
+// it, so that secrets do not linger on disk. Failures are ignored, since the
+// reply has already been read successfully.
+func removeReplyFile(file *os.File, filename string, size int64) {
+	if _, err := file.WriteAt(make([]byte, size), 0); err == nil {
+		_ = file.Sync()
+	}
+	_ = os.Remove(filename)
+}
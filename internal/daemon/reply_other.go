@@ -0,0 +1,39 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package daemon
+
+import (
+	"fmt"
+	"os"
+)
+
+// openReplyFile opens the reply file with flag, failing if it is a symlink.
+// Without O_NOFOLLOW, the path is checked before opening and the opened
+// file is checked to be the one that was inspected.
+func openReplyFile(filename string, flag int) (*os.File, error) {
+	before, err := os.Lstat(filename)
+	if err != nil {
+		return nil, err
+	}
+	if before.Mode()&os.ModeSymlink != 0 {
+		return nil, fmt.Errorf("%w: %s is not a regular file", ErrUnsafeReplyFile, filename)
+	}
+
+	file, err := os.OpenFile(filename, flag, 0)
+	if err != nil {
+		return nil, err
+	}
+	if after, err := file.Stat(); err != nil || !os.SameFile(before, after) {
+		file.Close()
+		return nil, fmt.Errorf("%w: %s was replaced while it was opened", ErrUnsafeReplyFile, filename)
+	}
+	return file, nil
+}
+
+// ownedByCurrentUser reports whether info describes a file owned by the
+// user running the process. File ownership is not checked on platforms
+// without Unix user IDs.
+func ownedByCurrentUser(info os.FileInfo) bool {
+	return true
+}
@@ -0,0 +1,22 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package daemon
+
+import (
+	"os"
+	"syscall"
+)
+
+// openReplyFile opens the reply file with flag, failing if it is a symlink.
+// O_NONBLOCK keeps a FIFO put in its place from blocking the open.
+func openReplyFile(filename string, flag int) (*os.File, error) {
+	return os.OpenFile(filename, flag|syscall.O_NOFOLLOW|syscall.O_NONBLOCK, 0)
+}
+
+// ownedByCurrentUser reports whether info describes a file owned by the
+// user running the process
+func ownedByCurrentUser(info os.FileInfo) bool {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	return ok && int(stat.Uid) == os.Getuid()
+}
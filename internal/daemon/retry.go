@@ -0,0 +1,112 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// idempotentEndpoints lists the endpoints that can be sent more than once
+// without the user noticing. Everything else (prints, tracking, prompts,
+// secrets, writes) is only retried if the daemon never received it.
+var idempotentEndpoints = map[string]bool{
+	"config/get":     true,
+	"config/get-all": true,
+	"state/get":      true,
+	"state/get-all":  true,
+	"user":           true,
+	"team":           true,
+	"events":         true,
+}
+
+// RetryPolicy controls how failed daemon requests are retried.
+//
+// A request is retried if the connection to the daemon could not be
+// established, since the daemon never saw it. Requests to idempotent
+// endpoints are also retried after other connection errors, such as resets,
+// and after 5xx responses.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between attempts
+	MaxBackoff time.Duration
+	// Multiplier is the factor applied to the delay after each attempt
+	Multiplier float64
+	// Jitter is the fraction of each delay that is randomized, from 0 to 1
+	Jitter float64
+	// Idempotent overrides whether an endpoint may be retried after the
+	// daemon might have received it, e.g. {"print": true}
+	Idempotent map[string]bool
+}
+
+// DefaultRetryPolicy returns a policy suited to waiting for a daemon that
+// is still starting up.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     2 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+// WithRetry enables retrying failed requests according to policy.
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = &policy
+	}
+}
+
+func (p *RetryPolicy) idempotent(endpoint string) bool {
+	if idempotent, ok := p.Idempotent[endpoint]; ok {
+		return idempotent
+	}
+	return idempotentEndpoints[endpoint]
+}
+
+func (p *RetryPolicy) shouldRetry(ctx context.Context, endpoint string, attempt int, err error) bool {
+	if p == nil || attempt >= p.MaxAttempts || ctx.Err() != nil {
+		return false
+	}
+
+	if errors.Is(err, ErrUnavailable) {
+		return true
+	}
+
+	if !p.idempotent(endpoint) {
+		return false
+	}
+
+	var daemonErr *Error
+	if errors.As(err, &daemonErr) {
+		return daemonErr.StatusCode >= http.StatusInternalServerError
+	}
+	return true
+}
+
+// wait sleeps before the next attempt, returning early if ctx is done
+func (p *RetryPolicy) wait(ctx context.Context, attempt int) error {
+	backoff := float64(p.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		backoff *= p.Multiplier
+	}
+	if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+		backoff = float64(p.MaxBackoff)
+	}
+	backoff -= backoff * p.Jitter * rand.Float64()
+
+	timer := time.NewTimer(time.Duration(backoff))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
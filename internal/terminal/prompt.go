@@ -0,0 +1,548 @@
+package terminal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cto-ai/sdk-go/v2/internal/daemon"
+)
+
+// prompt asks a single question and returns the answer keyed by its name
+func (t *Terminal) prompt(ctx context.Context, definition map[string]interface{}) (interface{}, error) {
+	answer, err := t.ask(ctx, definition)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{stringField(definition, "name"): answer}, nil
+}
+
+// prompts asks several questions in turn and returns all answers
+func (t *Terminal) prompts(ctx context.Context, body map[string]interface{}) (interface{}, error) {
+	definitions, _ := body["prompts"].([]interface{})
+	answers := make(map[string]interface{}, len(definitions))
+	for _, d := range definitions {
+		definition, ok := d.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("Invalid prompt definition %v", d)
+		}
+		answer, err := t.ask(ctx, definition)
+		if err != nil {
+			return nil, err
+		}
+		answers[stringField(definition, "name")] = answer
+	}
+	return answers, nil
+}
+
+func (t *Terminal) ask(ctx context.Context, definition map[string]interface{}) (interface{}, error) {
+	message := stringField(definition, "message")
+
+	switch promptType := stringField(definition, "type"); promptType {
+	case "input":
+		return t.askInput(ctx, message, definition)
+	case "number":
+		return t.askNumber(ctx, message, definition)
+	case "secret":
+		return t.askText(ctx, message, "", false, true)
+	case "password":
+		return t.askPassword(ctx, message, boolField(definition, "confirm"))
+	case "confirm":
+		return t.askConfirm(ctx, message, boolField(definition, "default"))
+	case "autocomplete":
+		if boolField(definition, "dynamic") {
+			return t.askSearch(ctx, message, definition)
+		}
+		return t.askList(ctx, message, definition, true)
+	case "list":
+		return t.askList(ctx, message, definition, false)
+	case "checkbox":
+		return t.askCheckbox(ctx, message, definition)
+	case "editor":
+		return t.askEditor(ctx, message, stringField(definition, "default"))
+	case "datetime":
+		return t.askDatetime(ctx, message, definition)
+	case "duration":
+		return t.askDuration(ctx, message, definition)
+	default:
+		return nil, fmt.Errorf("Prompt type %s is not supported in offline mode", promptType)
+	}
+}
+
+// askText reads a line of text, repeating the question if the answer is
+// empty and empty answers are not allowed
+func (t *Terminal) askText(ctx context.Context, message, defaultValue string, allowEmpty, hidden bool) (string, error) {
+	for {
+		if defaultValue != "" {
+			t.printf("? %s (%s) ", message, defaultValue)
+		} else {
+			t.printf("? %s ", message)
+		}
+
+		if hidden {
+			t.echo(false)
+		}
+		answer, err := t.readLine(ctx)
+		if hidden {
+			t.echo(true)
+			t.printf("\n")
+		}
+		if err != nil {
+			return "", err
+		}
+
+		answer = strings.TrimSpace(answer)
+		if answer == "" {
+			answer = defaultValue
+		}
+		if answer != "" || allowEmpty {
+			return answer, nil
+		}
+		t.printf("  Please enter a value\n")
+	}
+}
+
+// askInput reads a line of text, repeating the question while the answer
+// breaks the constraints of the definition
+func (t *Terminal) askInput(ctx context.Context, message string, definition map[string]interface{}) (interface{}, error) {
+	constraints := daemon.InputConstraints{
+		Pattern:      stringField(definition, "pattern"),
+		MinLength:    intField(definition, "minLength"),
+		MaxLength:    intField(definition, "maxLength"),
+		ErrorMessage: stringField(definition, "errorMessage"),
+	}
+
+	allowEmpty := boolField(definition, "allowEmpty")
+	for {
+		answer, err := t.askText(ctx, message, stringField(definition, "default"), allowEmpty, false)
+		if err != nil {
+			return nil, err
+		}
+		if answer == "" && allowEmpty {
+			return answer, nil
+		}
+		if err := constraints.CheckAnswer(answer); err != nil {
+			t.printf("  %v\n", err)
+			continue
+		}
+		return answer, nil
+	}
+}
+
+func (t *Terminal) askNumber(ctx context.Context, message string, definition map[string]interface{}) (interface{}, error) {
+	defaultValue := ""
+	if value, ok := definition["default"].(float64); ok {
+		defaultValue = strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	minimum, hasMinimum := definition["minimum"].(float64)
+	maximum, hasMaximum := definition["maximum"].(float64)
+
+	for {
+		answer, err := t.askText(ctx, message, defaultValue, false, false)
+		if err != nil {
+			return nil, err
+		}
+
+		num, err := strconv.ParseFloat(answer, 64)
+		switch {
+		case err != nil || !json.Valid([]byte(answer)):
+			t.printf("  Please enter a number\n")
+		case !boolField(definition, "float") && num != math.Trunc(num):
+			t.printf("  Please enter a whole number\n")
+		case hasMinimum && num < minimum:
+			t.printf("  Please enter a number no less than %v\n", minimum)
+		case hasMaximum && num > maximum:
+			t.printf("  Please enter a number no greater than %v\n", maximum)
+		default:
+			return json.Number(answer), nil
+		}
+	}
+}
+
+func (t *Terminal) askPassword(ctx context.Context, message string, confirm bool) (interface{}, error) {
+	for {
+		answer, err := t.askText(ctx, message, "", false, true)
+		if err != nil || !confirm {
+			return answer, err
+		}
+
+		again, err := t.askText(ctx, "Confirm password", "", false, true)
+		if err != nil {
+			return nil, err
+		}
+		if answer == again {
+			return answer, nil
+		}
+		t.printf("  Passwords do not match\n")
+	}
+}
+
+func (t *Terminal) askConfirm(ctx context.Context, message string, defaultValue bool) (interface{}, error) {
+	hint := "y/N"
+	if defaultValue {
+		hint = "Y/n"
+	}
+
+	for {
+		t.printf("? %s (%s) ", message, hint)
+		answer, err := t.readLine(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "":
+			return defaultValue, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+		t.printf("  Please answer y or n\n")
+	}
+}
+
+// choiceList holds the choices of a list or checkbox prompt, given either
+// as strings or as labeled choices
+type choiceList struct {
+	// shown is the text shown for each choice
+	shown []string
+	// values is the answer for each choice
+	values   []string
+	disabled map[int]bool
+}
+
+func choicesField(definition map[string]interface{}) choiceList {
+	list := choiceList{disabled: make(map[int]bool)}
+	choices, _ := definition["choices"].([]interface{})
+	for _, c := range choices {
+		switch c := c.(type) {
+		case string:
+			list.shown = append(list.shown, c)
+			list.values = append(list.values, c)
+		case map[string]interface{}:
+			shown := stringField(c, "label")
+			if description := stringField(c, "description"); description != "" {
+				shown += " - " + description
+			}
+			if boolField(c, "disabled") {
+				list.disabled[len(list.values)] = true
+				shown += " (unavailable)"
+			}
+			list.shown = append(list.shown, shown)
+			list.values = append(list.values, stringField(c, "value"))
+		}
+	}
+	return list
+}
+
+// printChoices lists choices with 1-based numbers, marking the selected ones
+func (t *Terminal) printChoices(choices []string, selected map[int]bool) {
+	for i, choice := range choices {
+		marker := " "
+		if selected[i] {
+			marker = "*"
+		}
+		t.printf("  %s %d) %s\n", marker, i+1, choice)
+	}
+}
+
+// choiceIndex resolves an answer given as a 1-based number or a choice
+func choiceIndex(choices []string, answer string) (int, bool) {
+	if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(choices) {
+		return n - 1, true
+	}
+	for i, choice := range choices {
+		if choice == answer {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// defaultIndexes resolves the default of a list or checkbox prompt, given
+// either as indexes or as values
+func defaultIndexes(choices []string, value interface{}) map[int]bool {
+	indexes := make(map[int]bool)
+	var values []interface{}
+	if list, ok := value.([]interface{}); ok {
+		values = list
+	} else if value != nil {
+		values = []interface{}{value}
+	}
+
+	for _, v := range values {
+		switch v := v.(type) {
+		case float64:
+			if int(v) >= 0 && int(v) < len(choices) {
+				indexes[int(v)] = true
+			}
+		case string:
+			for i, choice := range choices {
+				if choice == v {
+					indexes[i] = true
+				}
+			}
+		}
+	}
+	return indexes
+}
+
+func (t *Terminal) askList(ctx context.Context, message string, definition map[string]interface{}, autocomplete bool) (interface{}, error) {
+	list := choicesField(definition)
+	choices := list.shown
+	defaultValue := ""
+	for i := range defaultIndexes(list.values, definition["default"]) {
+		defaultValue = choices[i]
+	}
+
+	t.printf("? %s\n", message)
+	t.printChoices(choices, nil)
+
+	for {
+		answer, err := t.askText(ctx, "Choose", defaultValue, false, false)
+		if err != nil {
+			return nil, err
+		}
+
+		i, ok := choiceIndex(choices, answer)
+		if !ok && autocomplete {
+			var matches []int
+			for i, choice := range choices {
+				if strings.Contains(strings.ToLower(choice), strings.ToLower(answer)) {
+					matches = append(matches, i)
+				}
+			}
+			if len(matches) > 1 {
+				t.printf("  Matching choices:\n")
+				for _, match := range matches {
+					t.printf("    %s\n", choices[match])
+				}
+				continue
+			}
+			if ok = len(matches) == 1; ok {
+				i = matches[0]
+			}
+		}
+
+		switch {
+		case !ok:
+			t.printf("  Please choose one of the listed options\n")
+		case list.disabled[i]:
+			t.printf("  %s cannot be selected\n", choices[i])
+		default:
+			return list.values[i], nil
+		}
+	}
+}
+
+func (t *Terminal) askCheckbox(ctx context.Context, message string, definition map[string]interface{}) (interface{}, error) {
+	list := choicesField(definition)
+	choices := list.shown
+	defaults := defaultIndexes(list.values, definition["default"])
+	constraints := daemon.SelectionConstraints{
+		MinSelected: intField(definition, "minSelected"),
+		MaxSelected: intField(definition, "maxSelected"),
+	}
+
+	t.printf("? %s (comma-separated numbers, * marks the default)\n", message)
+	t.printChoices(choices, defaults)
+
+selecting:
+	for {
+		t.printf("? Choose ")
+		answer, err := t.readLine(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		selected := defaults
+		if answer = strings.TrimSpace(answer); answer != "" {
+			selected = make(map[int]bool)
+			for _, part := range strings.Split(answer, ",") {
+				i, ok := choiceIndex(choices, strings.TrimSpace(part))
+				if !ok {
+					t.printf("  %s is not one of the listed options\n", strings.TrimSpace(part))
+					continue selecting
+				}
+				if list.disabled[i] {
+					t.printf("  %s cannot be selected\n", choices[i])
+					continue selecting
+				}
+				selected[i] = true
+			}
+		}
+
+		if err := constraints.CheckSelected(len(selected)); err != nil {
+			t.printf("  %v\n", err)
+			continue
+		}
+
+		values := make([]interface{}, 0, len(selected))
+		for i, value := range list.values {
+			if selected[i] {
+				values = append(values, value)
+			}
+		}
+		return values, nil
+	}
+}
+
+// askEditor opens $EDITOR (vi if unset) on a temporary file holding the
+// default text and returns the saved contents
+func (t *Terminal) askEditor(ctx context.Context, message, defaultValue string) (interface{}, error) {
+	file, err := ioutil.TempFile("", "ctoai-editor-*.txt")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.WriteString(defaultValue); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := file.Close(); err != nil {
+		return nil, err
+	}
+
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+	fields := strings.Fields(editor)
+
+	t.printf("? %s\n", message)
+	cmd := exec.CommandContext(ctx, fields[0], append(fields[1:], file.Name())...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("Error running editor %s: %w", editor, err)
+	}
+
+	bytes, err := ioutil.ReadFile(file.Name())
+	if err != nil {
+		return nil, err
+	}
+	return string(bytes), nil
+}
+
+// datetimeLayouts are the accepted input formats for each datetime variant
+var datetimeLayouts = map[string][]string{
+	"datetime": {time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04"},
+	"date":     {"2006-01-02", time.RFC3339},
+	"time":     {"15:04:05", "15:04", time.RFC3339},
+}
+
+// askDuration reads a duration, repeating the question while it cannot be
+// parsed or is out of bounds, and returns it in the format of
+// time.Duration.String
+func (t *Terminal) askDuration(ctx context.Context, message string, definition map[string]interface{}) (interface{}, error) {
+	bounds := daemon.DurationPromptBody{
+		Minimum: stringField(definition, "minimum"),
+		Maximum: stringField(definition, "maximum"),
+	}
+
+	for {
+		answer, err := t.askText(ctx, message, stringField(definition, "default"), false, false)
+		if err != nil {
+			return nil, err
+		}
+
+		d, err := daemon.ParseDuration(answer)
+		if err == nil {
+			err = bounds.Check(d)
+		}
+		if err != nil {
+			t.printf("  %v\n", err)
+			continue
+		}
+		return d.String(), nil
+	}
+}
+
+func (t *Terminal) askDatetime(ctx context.Context, message string, definition map[string]interface{}) (interface{}, error) {
+	variant := stringField(definition, "variant")
+	layouts, ok := datetimeLayouts[variant]
+	if !ok {
+		variant = "datetime"
+		layouts = datetimeLayouts[variant]
+	}
+
+	parse := func(value string) (time.Time, bool) {
+		for _, layout := range layouts {
+			if parsed, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+				if variant == "time" && layout != time.RFC3339 {
+					now := time.Now()
+					parsed = time.Date(now.Year(), now.Month(), now.Day(), parsed.Hour(), parsed.Minute(), parsed.Second(), 0, time.Local)
+				}
+				return parsed, true
+			}
+		}
+		return time.Time{}, false
+	}
+
+	defaultValue := ""
+	if value := stringField(definition, "default"); value != "" {
+		if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+			defaultValue = parsed.Local().Format(layouts[0])
+		}
+	}
+	minimum, hasMinimum := parseRFC3339(stringField(definition, "minimum"))
+	maximum, hasMaximum := parseRFC3339(stringField(definition, "maximum"))
+
+	for {
+		answer, err := t.askText(ctx, fmt.Sprintf("%s [%s]", message, layouts[0]), defaultValue, false, false)
+		if err != nil {
+			return nil, err
+		}
+
+		parsed, ok := parse(answer)
+		switch {
+		case !ok:
+			t.printf("  Please enter a %s as %s\n", variant, layouts[0])
+		case hasMinimum && parsed.Before(minimum):
+			t.printf("  Please enter a %s no earlier than %s\n", variant, minimum.Local().Format(layouts[0]))
+		case hasMaximum && parsed.After(maximum):
+			t.printf("  Please enter a %s no later than %s\n", variant, maximum.Local().Format(layouts[0]))
+		default:
+			return parsed.Format(time.RFC3339), nil
+		}
+	}
+}
+
+func parseRFC3339(value string) (time.Time, bool) {
+	parsed, err := time.Parse(time.RFC3339, value)
+	return parsed, err == nil
+}
+
+func (t *Terminal) getSecret(ctx context.Context, key string) (interface{}, error) {
+	value, err := t.store.get("secrets", key)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		value, err = t.askText(ctx, fmt.Sprintf("Enter a value for secret %s", key), "", false, true)
+		if err != nil {
+			return nil, err
+		}
+		if err := t.store.set("secrets", key, value); err != nil {
+			return nil, err
+		}
+	}
+	return map[string]interface{}{key: value}, nil
+}
+
+func (t *Terminal) setSecret(key string, value interface{}) (interface{}, error) {
+	if err := t.store.set("secrets", key, value); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"key": key}, nil
+}
@@ -0,0 +1,102 @@
+package terminal
+
+import (
+	"context"
+)
+
+// search is the state of a dynamic autocomplete prompt being asked: the
+// query typed by the user, and the choices matching it once the SDK has
+// posted them
+type search struct {
+	query   string
+	results chan map[string]interface{}
+}
+
+// askSearch asks an autocomplete prompt whose choices are served by the SDK.
+// Each answer that is not one of the listed choices is a new query, whose
+// matches replace the listed choices.
+func (t *Terminal) askSearch(ctx context.Context, message string, definition map[string]interface{}) (interface{}, error) {
+	name := stringField(definition, "name")
+	s := &search{results: make(chan map[string]interface{}, 1)}
+	t.searchMu.Lock()
+	if t.searches == nil {
+		t.searches = make(map[string]*search)
+	}
+	t.searches[name] = s
+	t.searchMu.Unlock()
+	defer func() {
+		t.searchMu.Lock()
+		delete(t.searches, name)
+		t.searchMu.Unlock()
+	}()
+
+	list := choicesField(definition)
+	t.printf("? %s (type to search)\n", message)
+	t.printChoices(list.shown, nil)
+
+	for {
+		answer, err := t.askText(ctx, "Choose or search", "", false, false)
+		if err != nil {
+			return nil, err
+		}
+
+		if i, ok := choiceIndex(list.shown, answer); ok {
+			if !list.disabled[i] {
+				return list.values[i], nil
+			}
+			t.printf("  %s cannot be selected\n", list.shown[i])
+			continue
+		}
+
+		t.searchMu.Lock()
+		searched := s.query == answer
+		s.query = answer
+		t.searchMu.Unlock()
+		if searched {
+			t.printChoices(list.shown, nil)
+			continue
+		}
+
+		var results map[string]interface{}
+		select {
+		case results = <-s.results:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if message := stringField(results, "error"); message != "" {
+			t.printf("  %s\n", message)
+			continue
+		}
+		if list = choicesField(results); len(list.shown) == 0 {
+			t.printf("  No matching choices\n")
+			continue
+		}
+		t.printChoices(list.shown, nil)
+	}
+}
+
+// searchQuery returns the query typed into the autocomplete prompt called
+// name, or "" if it is not being asked
+func (t *Terminal) searchQuery(name string) string {
+	t.searchMu.Lock()
+	defer t.searchMu.Unlock()
+	if s, ok := t.searches[name]; ok {
+		return s.query
+	}
+	return ""
+}
+
+// searchResults hands the choices posted by the SDK to the autocomplete
+// prompt waiting for them; choices for an outdated query are dropped
+func (t *Terminal) searchResults(body map[string]interface{}) {
+	t.searchMu.Lock()
+	defer t.searchMu.Unlock()
+	s, ok := t.searches[stringField(body, "name")]
+	if !ok || s.query != stringField(body, "query") {
+		return
+	}
+	select {
+	case s.results <- body:
+	default:
+	}
+}
@@ -0,0 +1,114 @@
+package terminal
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// store keeps config, state and secrets in a JSON file, as a map of
+// section to key to value
+type store struct {
+	mu   sync.Mutex
+	path string
+}
+
+func (s *store) load() (map[string]map[string]interface{}, error) {
+	data := make(map[string]map[string]interface{})
+	bytes, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return data, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(bytes, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// save replaces the store file atomically. The file is only readable by the
+// current user, since it holds secrets.
+func (s *store) save(data map[string]map[string]interface{}) error {
+	bytes, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(bytes); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+func (s *store) get(section, key string) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	return data[section][key], nil
+}
+
+func (s *store) getAll(section string) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	values := data[section]
+	if values == nil {
+		values = map[string]interface{}{}
+	}
+	return values, nil
+}
+
+func (s *store) set(section, key string, value interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := s.load()
+	if err != nil {
+		return err
+	}
+	if data[section] == nil {
+		data[section] = make(map[string]interface{})
+	}
+	data[section][key] = value
+	return s.save(data)
+}
+
+func (s *store) delete(section, key string) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := data[section][key]; !ok {
+		return false, nil
+	}
+	delete(data[section], key)
+	return true, s.save(data)
+}
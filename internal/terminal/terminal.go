@@ -0,0 +1,222 @@
+// Package terminal serves SDK daemon requests locally, rendering prompts and
+// UX elements on a terminal. It lets ops run with `go run` outside of the
+// platform container, where there is no daemon.
+package terminal
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/cto-ai/sdk-go/v2/internal/daemon"
+)
+
+// Terminal renders daemon requests on a local terminal
+type Terminal struct {
+	in    io.Reader
+	out   io.Writer
+	store *store
+
+	// echo turns terminal echo off and back on around password input
+	echo func(on bool)
+
+	linesOnce sync.Once
+	lines     chan line
+
+	mu       sync.Mutex
+	spinner  *spinner
+	progress *progressBar
+
+	searchMu sync.Mutex
+	searches map[string]*search
+}
+
+type line struct {
+	text string
+	err  error
+}
+
+// New creates a Terminal reading answers from in, writing to out and
+// keeping config, state and secrets in the JSON file at storePath.
+func New(in io.Reader, out io.Writer, storePath string) *Terminal {
+	return &Terminal{
+		in:    in,
+		out:   out,
+		store: &store{path: storePath},
+		echo:  func(bool) {},
+	}
+}
+
+// NewStdio creates a Terminal on the process's stdin and stdout.
+func NewStdio(storePath string) *Terminal {
+	t := New(os.Stdin, os.Stdout, storePath)
+	if IsTerminal(os.Stdin) {
+		t.echo = setEcho
+	}
+	return t
+}
+
+// IsTerminal reports whether f is attached to a terminal
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Handle serves a daemon request locally. It has the signature of a
+// daemon.Handler.
+func (t *Terminal) Handle(ctx context.Context, req *daemon.Request) (interface{}, error) {
+	body, err := toObject(req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	switch req.Endpoint {
+	case "capabilities":
+		return daemon.FullCapabilities(daemon.ProtocolVersion), nil
+	case "prompt":
+		return t.prompt(ctx, body)
+	case "prompts":
+		return t.prompts(ctx, body)
+	case "autocomplete/query":
+		return map[string]interface{}{"query": t.searchQuery(stringField(body, "name"))}, nil
+	case "autocomplete/choices":
+		t.searchResults(body)
+		return nil, nil
+	case "print":
+		t.print(stringField(body, "text"))
+		return nil, nil
+	case "start-spinner":
+		t.startSpinner(stringField(body, "text"))
+		return nil, nil
+	case "stop-spinner":
+		t.stopSpinner(stringField(body, "text"))
+		return nil, nil
+	case "progress-bar/start":
+		t.startProgress(intField(body, "length"), intField(body, "initial"), stringField(body, "text"))
+		return nil, nil
+	case "progress-bar/advance":
+		t.advanceProgress(intField(body, "increment"))
+		return nil, nil
+	case "progress-bar/stop":
+		t.stopProgress(stringField(body, "text"))
+		return nil, nil
+	case "config/get":
+		return t.store.get("config", stringField(body, "key"))
+	case "config/get-all":
+		return t.store.getAll("config")
+	case "config/set":
+		return nil, t.store.set("config", stringField(body, "key"), body["value"])
+	case "config/delete":
+		return t.store.delete("config", stringField(body, "key"))
+	case "state/get":
+		return t.store.get("state", stringField(body, "key"))
+	case "state/get-all":
+		return t.store.getAll("state")
+	case "state/set":
+		return nil, t.store.set("state", stringField(body, "key"), body["value"])
+	case "secret/get":
+		return t.getSecret(ctx, stringField(body, "key"))
+	case "secret/set":
+		return t.setSecret(stringField(body, "key"), body["value"])
+	case "track":
+		return nil, nil
+	case "events":
+		return []interface{}{}, nil
+	case "user":
+		return map[string]interface{}{
+			"id":       "local",
+			"username": os.Getenv("USER"),
+			"email":    "",
+		}, nil
+	case "team":
+		return map[string]interface{}{
+			"id":   "local",
+			"name": "local",
+		}, nil
+	}
+
+	return nil, fmt.Errorf("Endpoint %s is not supported in offline mode", req.Endpoint)
+}
+
+// readLine reads a line of input, giving up when ctx is done. A single
+// goroutine reads the input so that an abandoned read does not lose the
+// next line.
+func (t *Terminal) readLine(ctx context.Context) (string, error) {
+	t.linesOnce.Do(func() {
+		t.lines = make(chan line)
+		go func() {
+			scanner := bufio.NewScanner(t.in)
+			for scanner.Scan() {
+				t.lines <- line{text: scanner.Text()}
+			}
+			err := scanner.Err()
+			if err == nil {
+				err = io.EOF
+			}
+			for {
+				t.lines <- line{err: err}
+			}
+		}()
+	})
+
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case l := <-t.lines:
+		return l.text, l.err
+	}
+}
+
+// printf writes to the output, clearing the line of any active spinner
+// first so that the text appears above it
+func (t *Terminal) printf(format string, args ...interface{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.printfLocked(format, args...)
+}
+
+func (t *Terminal) printfLocked(format string, args ...interface{}) {
+	if t.spinner != nil {
+		fmt.Fprint(t.out, "\r\033[K")
+	}
+	fmt.Fprintf(t.out, format, args...)
+}
+
+func (t *Terminal) print(text string) {
+	t.printf("%s\n", text)
+}
+
+// toObject converts a request body to its generic JSON object form
+func toObject(body interface{}) (map[string]interface{}, error) {
+	object := make(map[string]interface{})
+	if body == nil {
+		return object, nil
+	}
+	bytes, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("Error marshalling JSON body: %w", err)
+	}
+	if err := json.Unmarshal(bytes, &object); err != nil {
+		return nil, fmt.Errorf("Error unmarshalling JSON body: %w", err)
+	}
+	return object, nil
+}
+
+func stringField(object map[string]interface{}, key string) string {
+	value, _ := object[key].(string)
+	return value
+}
+
+func intField(object map[string]interface{}, key string) int {
+	value, _ := object[key].(float64)
+	return int(value)
+}
+
+func boolField(object map[string]interface{}, key string) bool {
+	value, _ := object[key].(bool)
+	return value
+}
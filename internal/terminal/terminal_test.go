@@ -0,0 +1,211 @@
+package terminal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cto-ai/sdk-go/v2/internal/daemon"
+)
+
+func newTestTerminal(t *testing.T, input string) (*Terminal, *bytes.Buffer, func()) {
+	dir, err := ioutil.TempDir("", "sdk-terminal")
+	if err != nil {
+		t.Fatalf("Error creating store dir: %v", err)
+	}
+
+	var out bytes.Buffer
+	term := New(strings.NewReader(input), &out, filepath.Join(dir, "offline.json"))
+	return term, &out, func() { os.RemoveAll(dir) }
+}
+
+func Test_Terminal_Prompts(t *testing.T) {
+	input := strings.Join([]string{
+		"",         // input: take the default
+		"AB", "ab", // constrained input: reject pattern mismatch, then accept
+		"abc", "2.5", "7", // number: reject non-numeric and fractional, then accept
+		"s3cret", // secret
+		"a", "b", // password: mismatched confirmation
+		"pw", "pw", // password: matching confirmation
+		"maybe", "y", // confirm
+		"2",      // list by number
+		"clo",    // autocomplete by unique substring
+		"3", "2", // labeled list: reject disabled, then accept
+		"1, 3",      // checkbox
+		"1, 2", "2", // constrained checkbox: reject two, then accept one
+		"2020-01-02",                   // date
+		"soon", "3 days", "90 minutes", // duration: reject unparseable and too long, then accept
+	}, "\n") + "\n"
+
+	term, _, cleanup := newTestTerminal(t, input)
+	defer cleanup()
+
+	prompts := []struct {
+		body     interface{}
+		expected interface{}
+	}{
+		{daemon.InputPromptBody{PromptEnvelope: daemon.PromptEnvelope{Name: "input", PromptType: "input"}, Default: "def"}, "def"},
+		{daemon.InputPromptBody{PromptEnvelope: daemon.PromptEnvelope{Name: "constrained", PromptType: "input"}, InputConstraints: daemon.InputConstraints{Pattern: "[a-z]+", MaxLength: 5}}, "ab"},
+		{daemon.NumberPromptBody{PromptEnvelope: daemon.PromptEnvelope{Name: "number"}, MinimumValue: 1, MinimumSet: true}, json.Number("7")},
+		{daemon.SecretPromptBody{Name: "secret", PromptType: "secret"}, "s3cret"},
+		{daemon.PasswordPromptBody{PromptEnvelope: daemon.PromptEnvelope{Name: "password", PromptType: "password"}, Confirm: true}, "pw"},
+		{daemon.ConfirmPromptBody{PromptEnvelope: daemon.PromptEnvelope{Name: "confirm", PromptType: "confirm"}}, true},
+		{daemon.ListPromptBody{PromptEnvelope: daemon.PromptEnvelope{Name: "list", PromptType: "list"}, Choices: []string{"AWS", "GCP"}}, "GCP"},
+		{daemon.ListPromptBody{PromptEnvelope: daemon.PromptEnvelope{Name: "auto", PromptType: "autocomplete"}, Choices: []string{"AWS", "Google Cloud"}}, "Google Cloud"},
+		{daemon.ListPromptBody{PromptEnvelope: daemon.PromptEnvelope{Name: "labeled", PromptType: "list"}, Labeled: []daemon.LabeledChoice{{Value: "0", Label: "prod"}, {Value: "1", Label: "staging", Description: "eu"}, {Value: "2", Label: "old", Disabled: true}}}, "1"},
+		{daemon.CheckboxPromptBody{PromptEnvelope: daemon.PromptEnvelope{Name: "checkbox", PromptType: "checkbox"}, Choices: []string{"a", "b", "c"}}, []interface{}{"a", "c"}},
+		{daemon.CheckboxPromptBody{PromptEnvelope: daemon.PromptEnvelope{Name: "constrained checkbox", PromptType: "checkbox"}, SelectionConstraints: daemon.SelectionConstraints{MinSelected: 1, MaxSelected: 1}, Choices: []string{"a", "b"}}, []interface{}{"b"}},
+		{daemon.DatetimePromptBody{PromptEnvelope: daemon.PromptEnvelope{Name: "date", PromptType: "datetime"}, Variant: "date"}, "2020-01-02T00:00:00"},
+		{daemon.DurationPromptBody{PromptEnvelope: daemon.PromptEnvelope{Name: "duration", PromptType: "duration"}, Maximum: "48h0m0s"}, "1h30m0s"},
+	}
+
+	for _, prompt := range prompts {
+		value, err := term.Handle(context.Background(), &daemon.Request{Kind: daemon.Async, Endpoint: "prompt", Method: "POST", Body: prompt.body})
+		if err != nil {
+			t.Fatalf("Error in prompt %+v: %v", prompt.body, err)
+		}
+
+		for _, answer := range value.(map[string]interface{}) {
+			if s, ok := answer.(string); ok && strings.HasPrefix(s, "2020-01-02T00:00:00") {
+				answer = "2020-01-02T00:00:00"
+			}
+			if !reflect.DeepEqual(answer, prompt.expected) {
+				t.Errorf("Error unexpected answer %#v to %+v", answer, prompt.body)
+			}
+		}
+	}
+}
+
+func Test_Terminal_HiddenInput(t *testing.T) {
+	term, _, cleanup := newTestTerminal(t, "s3cret\npw\n")
+	defer cleanup()
+
+	var echoes []bool
+	term.echo = func(on bool) { echoes = append(echoes, on) }
+
+	prompts := []interface{}{
+		daemon.SecretPromptBody{Name: "secret", PromptType: "secret"},
+		daemon.PasswordPromptBody{PromptEnvelope: daemon.PromptEnvelope{Name: "password", PromptType: "password"}},
+	}
+	for _, body := range prompts {
+		echoes = nil
+		if _, err := term.Handle(context.Background(), &daemon.Request{Kind: daemon.Async, Endpoint: "prompt", Method: "POST", Body: body}); err != nil {
+			t.Fatalf("Error in prompt %+v: %v", body, err)
+		}
+		if !reflect.DeepEqual(echoes, []bool{false, true}) {
+			t.Errorf("Error expected echo off while reading %+v, got: %v", body, echoes)
+		}
+	}
+}
+
+func Test_Terminal_Store(t *testing.T) {
+	term, _, cleanup := newTestTerminal(t, "hunter2\n")
+	defer cleanup()
+
+	handle := func(endpoint string, body interface{}) interface{} {
+		value, err := term.Handle(context.Background(), &daemon.Request{Endpoint: endpoint, Method: "POST", Body: body})
+		if err != nil {
+			t.Fatalf("Error in %s request: %v", endpoint, err)
+		}
+		return value
+	}
+
+	handle("config/set", map[string]string{"key": "region", "value": "eu"})
+	if value := handle("config/get", map[string]string{"key": "region"}); value != "eu" {
+		t.Errorf("Error unexpected config value: %v", value)
+	}
+	if value := handle("config/get-all", nil); !reflect.DeepEqual(value, map[string]interface{}{"region": "eu"}) {
+		t.Errorf("Error unexpected config values: %v", value)
+	}
+	if value := handle("config/delete", map[string]string{"key": "region"}); value != true {
+		t.Errorf("Error unexpected delete result: %v", value)
+	}
+	if value := handle("config/get", map[string]string{"key": "region"}); value != nil {
+		t.Errorf("Error unexpected deleted config value: %v", value)
+	}
+
+	// The first request prompts for the secret, the second finds it stored
+	for i := 0; i < 2; i++ {
+		value := handle("secret/get", daemon.GetSecretBody{Key: "token"})
+		if !reflect.DeepEqual(value, map[string]interface{}{"token": "hunter2"}) {
+			t.Errorf("Error unexpected secret: %v", value)
+		}
+	}
+}
+
+func Test_Terminal_Ux(t *testing.T) {
+	term, out, cleanup := newTestTerminal(t, "")
+	defer cleanup()
+
+	requests := []daemon.Request{
+		{Endpoint: "print", Body: daemon.PrintBody{Text: "hello"}},
+		{Endpoint: "progress-bar/start", Body: daemon.ProgressBarStartBody{Length: 4, Initial: 1, Text: "Working"}},
+		{Endpoint: "progress-bar/advance", Body: daemon.ProgressBarAdvanceBody{Increment: 1}},
+		{Endpoint: "progress-bar/stop", Body: daemon.ProgressBarStopBody{Text: "Done"}},
+		{Endpoint: "start-spinner", Body: daemon.SpinnerStartBody{Text: "Spinning"}},
+		{Endpoint: "stop-spinner", Body: daemon.SpinnerStopBody{Text: "Stopped"}},
+	}
+	for _, req := range requests {
+		req := req
+		if _, err := term.Handle(context.Background(), &req); err != nil {
+			t.Fatalf("Error in %s request: %v", req.Endpoint, err)
+		}
+	}
+
+	for _, expected := range []string{"hello\n", "2/4 Working", "4/4 Done", "✔ Stopped\n"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Error output missing %q: %q", expected, out.String())
+		}
+	}
+}
+
+func Test_Terminal_Search(t *testing.T) {
+	term, _, cleanup := newTestTerminal(t, "sdk\n2\n")
+	defer cleanup()
+
+	type result struct {
+		value interface{}
+		err   error
+	}
+	answered := make(chan result, 1)
+	go func() {
+		body := daemon.AutocompletePromptBody{
+			PromptEnvelope: daemon.PromptEnvelope{Name: "repo", PromptType: "autocomplete"},
+			Dynamic:        true,
+			Choices:        []daemon.LabeledChoice{{Value: "0", Label: "ops"}},
+		}
+		value, err := term.Handle(context.Background(), &daemon.Request{Kind: daemon.Async, Endpoint: "prompt", Method: "POST", Body: body})
+		answered <- result{value, err}
+	}()
+
+	query := &daemon.Request{Kind: daemon.Sync, Endpoint: "autocomplete/query", Method: "POST", Body: daemon.AutocompleteQueryBody{Name: "repo"}}
+	for {
+		value, err := term.Handle(context.Background(), query)
+		if err != nil {
+			t.Fatalf("Error in query request: %v", err)
+		}
+		if value.(map[string]interface{})["query"] == "sdk" {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	choices := daemon.AutocompleteChoicesBody{Name: "repo", Query: "sdk", Choices: []daemon.LabeledChoice{{Value: "1", Label: "sdk-go"}, {Value: "2", Label: "sdk-js"}}}
+	if _, err := term.Handle(context.Background(), &daemon.Request{Kind: daemon.Simple, Endpoint: "autocomplete/choices", Method: "POST", Body: choices}); err != nil {
+		t.Fatalf("Error in choices request: %v", err)
+	}
+
+	answer := <-answered
+	if answer.err != nil {
+		t.Fatalf("Error in prompt: %v", answer.err)
+	}
+	if !reflect.DeepEqual(answer.value, map[string]interface{}{"repo": "2"}) {
+		t.Errorf("Error unexpected answer: %v", answer.value)
+	}
+}
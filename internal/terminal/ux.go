@@ -0,0 +1,138 @@
+package terminal
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+const spinnerInterval = 100 * time.Millisecond
+
+const progressWidth = 30
+
+type spinner struct {
+	text string
+	done chan struct{}
+}
+
+type progressBar struct {
+	length  int
+	current int
+	text    string
+}
+
+// startSpinner shows text with an animated spinner until stopSpinner is
+// called. Starting a new spinner replaces the current one.
+func (t *Terminal) startSpinner(text string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.spinner != nil {
+		close(t.spinner.done)
+	}
+	s := &spinner{text: text, done: make(chan struct{})}
+	t.spinner = s
+
+	go func() {
+		ticker := time.NewTicker(spinnerInterval)
+		defer ticker.Stop()
+		for frame := 0; ; frame++ {
+			t.mu.Lock()
+			if t.spinner == s {
+				fmt.Fprintf(t.out, "\r\033[K%s %s", spinnerFrames[frame%len(spinnerFrames)], s.text)
+			}
+			t.mu.Unlock()
+
+			select {
+			case <-s.done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (t *Terminal) stopSpinner(text string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.spinner == nil {
+		return
+	}
+	if text == "" {
+		text = t.spinner.text
+	}
+	close(t.spinner.done)
+	t.spinner = nil
+	fmt.Fprintf(t.out, "\r\033[K✔ %s\n", text)
+}
+
+func (t *Terminal) startProgress(length, initial int, text string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.progress = &progressBar{length: length, current: initial, text: text}
+	t.renderProgress()
+}
+
+func (t *Terminal) advanceProgress(increment int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.progress == nil {
+		return
+	}
+	if increment == 0 {
+		increment = 1
+	}
+	t.progress.current += increment
+	if t.progress.current > t.progress.length {
+		t.progress.current = t.progress.length
+	}
+	t.renderProgress()
+}
+
+func (t *Terminal) stopProgress(text string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.progress == nil {
+		return
+	}
+	t.progress.current = t.progress.length
+	if text != "" {
+		t.progress.text = text
+	}
+	t.renderProgress()
+	fmt.Fprintln(t.out)
+	t.progress = nil
+}
+
+// renderProgress redraws the progress bar; t.mu must be held
+func (t *Terminal) renderProgress() {
+	p := t.progress
+	filled := 0
+	if p.length > 0 {
+		filled = progressWidth * p.current / p.length
+	}
+	fmt.Fprintf(t.out, "\r\033[K[%s%s] %d/%d %s",
+		strings.Repeat("#", filled),
+		strings.Repeat("-", progressWidth-filled),
+		p.current, p.length, p.text)
+}
+
+// setEcho turns echoing of typed characters on the controlling terminal on
+// or off
+func setEcho(on bool) {
+	arg := "-echo"
+	if on {
+		arg = "echo"
+	}
+	cmd := exec.Command("stty", arg)
+	cmd.Stdin = os.Stdin
+	_ = cmd.Run()
+}
@@ -0,0 +1,95 @@
+package ctoai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/cto-ai/sdk-go/v2/internal/daemon"
+)
+
+func Test_Middleware_Observes(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/config/get":
+			fmt.Fprintf(w, `{"value": "config-value"}`)
+		case "/prompt":
+			fmt.Fprintf(w, `{"replyFilename": "/tmp/response-mocktest"}`)
+		}
+	}))
+	defer ts.Close()
+
+	err := ioutil.WriteFile("/tmp/response-mocktest", []byte(`{"test": "test"}`), 0600)
+	if err != nil {
+		t.Fatalf("Error writing reply file: %v", err)
+	}
+
+	type call struct {
+		kind     DaemonRequestKind
+		endpoint string
+		method   string
+		value    interface{}
+	}
+	var calls []call
+	var order []string
+
+	c := NewClient(OptClientPort(ServerPort(t, ts)))
+	c.Use(func(next Handler) Handler {
+		return func(ctx context.Context, req *DaemonRequest) (interface{}, error) {
+			order = append(order, "outer")
+			value, err := next(ctx, req)
+			calls = append(calls, call{req.Kind, req.Endpoint, req.Method, value})
+			return value, err
+		}
+	}, func(next Handler) Handler {
+		return func(ctx context.Context, req *DaemonRequest) (interface{}, error) {
+			order = append(order, "inner")
+			return next(ctx, req)
+		}
+	})
+
+	if _, err := c.Sdk.GetConfig("test-key"); err != nil {
+		t.Errorf("Error in config request: %v", err)
+	}
+	if _, err := c.Prompt.Input("test", "type test"); err != nil {
+		t.Errorf("Error in prompt request: %v", err)
+	}
+
+	expected := []call{
+		{RequestSync, "config/get", "POST", "config-value"},
+		{RequestAsync, "prompt", "POST", map[string]interface{}{"test": "test"}},
+	}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Error unexpected calls: %+v", calls)
+	}
+	if !reflect.DeepEqual(order, []string{"outer", "inner", "outer", "inner"}) {
+		t.Errorf("Error unexpected middleware order: %v", order)
+	}
+}
+
+func Test_Middleware_FaultInjection(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Error request should not reach the daemon: %v", r.URL.Path)
+	}))
+	defer ts.Close()
+
+	injected := errors.New("injected")
+	c := NewClient(OptClientPort(ServerPort(t, ts)))
+	c.Use(func(next Handler) Handler {
+		return func(ctx context.Context, req *DaemonRequest) (interface{}, error) {
+			if body, ok := req.Body.(daemon.PrintBody); ok && body.Text == "fail" {
+				return nil, injected
+			}
+			return next(ctx, req)
+		}
+	})
+
+	if err := c.Ux.Print("fail"); !errors.Is(err, injected) {
+		t.Errorf("Error expected injected error, got: %v", err)
+	}
+}
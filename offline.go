@@ -0,0 +1,78 @@
+package ctoai
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/cto-ai/sdk-go/v2/internal/daemon"
+	"github.com/cto-ai/sdk-go/v2/internal/terminal"
+)
+
+var (
+	stdioTerminalOnce sync.Once
+	stdioTerminal     *terminal.Terminal
+)
+
+// offlineTerminal returns the terminal used in offline mode. It is shared by
+// all clients, since they all read from the same stdin.
+func offlineTerminal() *terminal.Terminal {
+	stdioTerminalOnce.Do(func() {
+		stdioTerminal = terminal.NewStdio(offlineStorePath())
+	})
+	return stdioTerminal
+}
+
+// offlineStorePath is the JSON file holding config, state and secrets in
+// offline mode: .ctoai/offline.json under SDK_HOME_DIR, or under the user's
+// home directory if that is unset.
+func offlineStorePath() string {
+	home := os.Getenv("SDK_HOME_DIR")
+	if home == "" {
+		var err error
+		if home, err = os.UserHomeDir(); err != nil {
+			home = "."
+		}
+	}
+	return filepath.Join(home, ".ctoai", "offline.json")
+}
+
+// offlineFromEnv reports whether SDK_OFFLINE turns on offline mode
+func offlineFromEnv() bool {
+	enabled, err := strconv.ParseBool(os.Getenv("SDK_OFFLINE"))
+	return err == nil && enabled
+}
+
+// OptClientOffline controls offline mode, in which the SDK works without a
+// daemon: prompts, prints, spinners and progress bars are rendered on the
+// local terminal, and config, state and secrets are kept in
+// .ctoai/offline.json under the home directory.
+//
+// Offline mode is off by default, so that a missing daemon is reported as
+// ErrDaemonUnavailable. Setting the SDK_OFFLINE environment variable to
+// true turns it on when no daemon is configured, i.e. SDK_SPEAK_PORT and
+// SDK_SPEAK_SOCKET are unset, so that ops can be run with
+// `SDK_OFFLINE=1 go run .` during development. OptClientOffline(true)
+// forces offline mode and OptClientOffline(false) disables it regardless
+// of SDK_OFFLINE.
+//
+// Offline mode is meant for development only: secrets are stored as
+// plaintext JSON in .ctoai/offline.json, protected only by the file being
+// readable by the current user alone.
+func OptClientOffline(enabled bool) ClientOption {
+	if !enabled {
+		return transportOption(daemon.WithFallback(nil, false))
+	}
+	return transportOption(daemon.WithFallback(offlineTerminal().Handle, true))
+}
+
+// newTransport creates the daemon transport for the given options, falling
+// back to offline mode without a daemon if SDK_OFFLINE is set.
+func newTransport(o *clientOptions) *daemon.Client {
+	options := o.transport
+	if offlineFromEnv() {
+		options = append([]daemon.Option{daemon.WithFallback(offlineTerminal().Handle, false)}, options...)
+	}
+	return daemon.New(options...)
+}
@@ -0,0 +1,166 @@
+// Package otelctoai instruments the CTO.ai Ops SDK with OpenTelemetry
+// tracing.
+//
+// Every daemon request made by a client becomes a span named after the
+// daemon endpoint (e.g. "prompt", "config/get"), so that time spent waiting
+// on the user or the daemon shows up in traces.
+//
+// Only request metadata is recorded: the prompt type, name and flag, or
+// the names and types of the prompts asked together by a form, the status
+// code and the latency. Prompt answers, secret values and other
+// request or response bodies never are.
+//
+// Example:
+//
+//  client := ctoai.NewClient()
+//  client.Use(otelctoai.Middleware())
+package otelctoai
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	ctoai "github.com/cto-ai/sdk-go/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope name used for the tracer
+const ScopeName = "github.com/cto-ai/sdk-go/v2/otelctoai"
+
+// Attribute keys recorded on spans
+const (
+	EndpointKey   = attribute.Key("ctoai.endpoint")
+	KindKey       = attribute.Key("ctoai.request.kind")
+	MethodKey     = attribute.Key("http.request.method")
+	StatusCodeKey = attribute.Key("http.response.status_code")
+	LatencyKey    = attribute.Key("ctoai.latency_ms")
+	PromptTypeKey = attribute.Key("ctoai.prompt.type")
+	PromptNameKey = attribute.Key("ctoai.prompt.name")
+	PromptFlagKey = attribute.Key("ctoai.prompt.flag")
+
+	// PromptNamesKey and PromptTypesKey list the prompts asked together
+	// on the "prompts" endpoint, in order
+	PromptNamesKey = attribute.Key("ctoai.prompts.names")
+	PromptTypesKey = attribute.Key("ctoai.prompts.types")
+)
+
+type config struct {
+	tracerProvider trace.TracerProvider
+}
+
+// Option configures the middleware
+type Option func(*config)
+
+// WithTracerProvider sets the TracerProvider used to create spans. Defaults
+// to the global provider.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = tp
+	}
+}
+
+// Middleware returns a ctoai.Middleware that traces every daemon request.
+func Middleware(options ...Option) ctoai.Middleware {
+	cfg := config{}
+	for _, option := range options {
+		option(&cfg)
+	}
+	if cfg.tracerProvider == nil {
+		cfg.tracerProvider = otel.GetTracerProvider()
+	}
+	tracer := cfg.tracerProvider.Tracer(ScopeName)
+
+	return func(next ctoai.Handler) ctoai.Handler {
+		return func(ctx context.Context, req *ctoai.DaemonRequest) (interface{}, error) {
+			attrs := []attribute.KeyValue{
+				EndpointKey.String(req.Endpoint),
+				KindKey.String(req.Kind.String()),
+				MethodKey.String(req.Method),
+			}
+			switch req.Endpoint {
+			case "prompt":
+				attrs = append(attrs, promptAttributes(req.Body)...)
+			case "prompts":
+				attrs = append(attrs, promptsAttributes(req.Body)...)
+			}
+
+			ctx, span := tracer.Start(ctx, req.Endpoint,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(attrs...),
+			)
+			defer span.End()
+
+			start := time.Now()
+			value, err := next(ctx, req)
+			latency := time.Since(start)
+
+			span.SetAttributes(LatencyKey.Float64(float64(latency) / float64(time.Millisecond)))
+			if req.StatusCode != 0 {
+				span.SetAttributes(StatusCodeKey.Int(req.StatusCode))
+			}
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+
+			return value, err
+		}
+	}
+}
+
+// promptEnvelope is the metadata recorded from a prompt body
+type promptEnvelope struct {
+	Name       string `json:"name"`
+	PromptType string `json:"type"`
+	Flag       string `json:"flag"`
+}
+
+// promptAttributes extracts the prompt type, name and flag from a prompt
+// body, leaving out everything else, such as defaults.
+func promptAttributes(body interface{}) []attribute.KeyValue {
+	bytes, err := json.Marshal(body)
+	if err != nil {
+		return nil
+	}
+
+	var envelope promptEnvelope
+	if err := json.Unmarshal(bytes, &envelope); err != nil {
+		return nil
+	}
+
+	attrs := []attribute.KeyValue{
+		PromptTypeKey.String(envelope.PromptType),
+		PromptNameKey.String(envelope.Name),
+	}
+	if envelope.Flag != "" {
+		attrs = append(attrs, PromptFlagKey.String(envelope.Flag))
+	}
+	return attrs
+}
+
+// promptsAttributes extracts the names and types of the prompts in the
+// body of a "prompts" request
+func promptsAttributes(body interface{}) []attribute.KeyValue {
+	bytes, err := json.Marshal(body)
+	if err != nil {
+		return nil
+	}
+
+	var batch struct {
+		Prompts []promptEnvelope `json:"prompts"`
+	}
+	if err := json.Unmarshal(bytes, &batch); err != nil {
+		return nil
+	}
+
+	names := make([]string, len(batch.Prompts))
+	types := make([]string, len(batch.Prompts))
+	for i, envelope := range batch.Prompts {
+		names[i], types[i] = envelope.Name, envelope.PromptType
+	}
+	return []attribute.KeyValue{PromptNamesKey.StringSlice(names), PromptTypesKey.StringSlice(types)}
+}
@@ -0,0 +1,173 @@
+package otelctoai
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	ctoai "github.com/cto-ai/sdk-go/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc, options ...ctoai.ClientOption) ctoai.Client {
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+
+	_, portStr, err := net.SplitHostPort(ts.URL[7:])
+	if err != nil {
+		t.Fatalf("Error splitting host port: %s", err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		t.Fatalf("Error parsing port: %s", err)
+	}
+
+	return ctoai.NewClient(append([]ctoai.ClientOption{ctoai.OptClientPort(port)}, options...)...)
+}
+
+func attributes(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func Test_Middleware_Prompt(t *testing.T) {
+	dir, err := ioutil.TempDir("", "otelctoai")
+	if err != nil {
+		t.Fatalf("Error creating reply dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "response")
+
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		ioutil.WriteFile(filename, []byte(`{"password": "hunter2"}`), 0600)
+		fmt.Fprintf(w, `{"replyFilename": %q}`, filename)
+	}, ctoai.OptClientReplyDir(dir))
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	c.Use(Middleware(WithTracerProvider(tp)))
+
+	output, err := c.Prompt.Password("password", "What is your password?", ctoai.OptPasswordFlag("p"))
+	if err != nil {
+		t.Fatalf("Error in prompt request: %v", err)
+	}
+	if output != "hunter2" {
+		t.Errorf("Error unexpected output: %v", output)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Error expected one span, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name != "prompt" {
+		t.Errorf("Error unexpected span name: %v", span.Name)
+	}
+
+	attrs := attributes(span)
+	expected := map[attribute.Key]string{
+		EndpointKey:   "prompt",
+		PromptTypeKey: "password",
+		PromptNameKey: "password",
+		PromptFlagKey: "p",
+	}
+	for key, value := range expected {
+		if attrs[key].AsString() != value {
+			t.Errorf("Error unexpected %s attribute: %v", key, attrs[key].Emit())
+		}
+	}
+	if attrs[StatusCodeKey].AsInt64() != http.StatusOK {
+		t.Errorf("Error unexpected status code attribute: %v", attrs[StatusCodeKey].Emit())
+	}
+	if _, ok := attrs[LatencyKey]; !ok {
+		t.Errorf("Error missing latency attribute")
+	}
+
+	for _, kv := range span.Attributes {
+		if strings.Contains(kv.Value.Emit(), "hunter2") {
+			t.Errorf("Error secret value recorded in attribute %s", kv.Key)
+		}
+	}
+}
+
+func Test_Middleware_Error(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(w, `{"message": "no such key"}`)
+	})
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	c.Use(Middleware(WithTracerProvider(tp)))
+
+	if _, err := c.Sdk.GetConfig("key"); err == nil {
+		t.Fatalf("Error expected config request to fail")
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Error expected one span, got %d", len(spans))
+	}
+	if spans[0].Name != "config/get" {
+		t.Errorf("Error unexpected span name: %v", spans[0].Name)
+	}
+	if spans[0].Status.Code != codes.Error {
+		t.Errorf("Error unexpected span status: %v", spans[0].Status)
+	}
+	if attributes(spans[0])[StatusCodeKey].AsInt64() != http.StatusNotFound {
+		t.Errorf("Error unexpected status code attribute")
+	}
+}
+
+func Test_Middleware_Form(t *testing.T) {
+	c := ctoai.NewClient(ctoai.OptClientHandler(func(ctx context.Context, req *ctoai.DaemonRequest) (interface{}, error) {
+		switch req.Endpoint {
+		case "capabilities":
+			return map[string]interface{}{
+				"version":   "1",
+				"endpoints": []string{"capabilities", "prompt", "prompts"},
+				"prompts":   []string{"input", "confirm"},
+			}, nil
+		case "prompts":
+			return map[string]interface{}{"service": "api", "canary": true}, nil
+		}
+		t.Errorf("Error unexpected request to %s", req.Endpoint)
+		return nil, nil
+	}))
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	c.Use(Middleware(WithTracerProvider(tp)))
+
+	_, err := c.Prompt.Form().Input("service", "Which service?").Confirm("canary", "Canary?").Run()
+	if err != nil {
+		t.Fatalf("Error in prompt request: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 || spans[1].Name != "prompts" {
+		t.Fatalf("Error unexpected spans: %v", spans)
+	}
+	attrs := attributes(spans[1])
+	if names := attrs[PromptNamesKey].AsStringSlice(); !reflect.DeepEqual(names, []string{"service", "canary"}) {
+		t.Errorf("Error unexpected prompt names: %v", names)
+	}
+	if types := attrs[PromptTypesKey].AsStringSlice(); !reflect.DeepEqual(types, []string{"input", "confirm"}) {
+		t.Errorf("Error unexpected prompt types: %v", types)
+	}
+}
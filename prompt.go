@@ -1,17 +1,33 @@
 package ctoai
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"math"
+	"math/big"
+	"strconv"
 	"time"
 
 	"github.com/cto-ai/sdk-go/v2/internal/daemon"
 )
 
 // Prompt is the object that contains the prompt methods
-type Prompt struct{}
+type Prompt struct {
+	transport *daemon.Client
+	ux        UX
+	attempts  int
+}
+
+func NewPrompt(options ...ClientOption) *Prompt {
+	o := newClientOptions(options)
+	transport := newTransport(o)
+	return newPrompt(transport, newUx(transport, o), o)
+}
 
-func NewPrompt() *Prompt {
-	return &Prompt{}
+// newPrompt creates a Prompt printing validation messages through ux
+func newPrompt(transport *daemon.Client, ux UX, o *clientOptions) *Prompt {
+	return &Prompt{transport: transport, ux: ux, attempts: o.attempts}
 }
 
 // InputOption is an option for the Input prompt function
@@ -42,6 +58,68 @@ func OptInputAllowEmpty(allowEmpty bool) InputOption {
 	}
 }
 
+// OptInputPattern sets a regular expression that the whole answer to the
+// input prompt must match, e.g. `[a-z0-9-]+`.
+//
+// The daemon enforces the pattern where it can, and the answer is checked
+// again like with OptInputValidate. Patterns should use the syntax common to
+// Go and JavaScript regular expressions. Empty answers are not checked if
+// OptInputAllowEmpty is set.
+func OptInputPattern(pattern string) InputOption {
+	return func(definition *daemon.InputPromptBody) {
+		definition.Pattern = pattern
+	}
+}
+
+// OptInputMinLength sets the minimum length, in characters, of the answer to
+// the input prompt; see OptInputPattern.
+func OptInputMinLength(minLength int) InputOption {
+	return func(definition *daemon.InputPromptBody) {
+		definition.MinLength = minLength
+	}
+}
+
+// OptInputMaxLength sets the maximum length, in characters, of the answer to
+// the input prompt; see OptInputPattern.
+func OptInputMaxLength(maxLength int) InputOption {
+	return func(definition *daemon.InputPromptBody) {
+		definition.MaxLength = maxLength
+	}
+}
+
+// OptInputErrorMessage sets the message shown when the answer to the input
+// prompt breaks its pattern or length constraints.
+func OptInputErrorMessage(message string) InputOption {
+	return func(definition *daemon.InputPromptBody) {
+		definition.ErrorMessage = message
+	}
+}
+
+// OptInputValidate sets a function that checks the answer to the input
+// prompt. If it returns an error, its message is printed and the prompt is
+// asked again, with the rejected answer as the default, up to the number
+// of attempts set with OptClientValidationAttempts.
+//
+// Example:
+//
+//  import "golang.org/x/mod/semver"
+//
+//  p := ctoai.NewPrompt()
+//  tag, err := p.Input("tag", "Which version?", ctoai.OptInputValidate(func(answer string) error {
+//      if !semver.IsValid(answer) {
+//          return fmt.Errorf("%q is not a semantic version such as v1.2.3", answer)
+//      }
+//      return nil
+//  }))
+func OptInputValidate(validate func(string) error) InputOption {
+	return func(definition *daemon.InputPromptBody) {
+		definition.Validate = func(answer interface{}) error {
+			value, _ := answer.(string)
+			return validate(value)
+		}
+	}
+}
+
 // Input presents an input (single-line text) prompt on the interface
 // (i.e. terminal or slack).
 //
@@ -59,7 +137,28 @@ func OptInputAllowEmpty(allowEmpty bool) InputOption {
 //
 // Output:
 // good
-func (*Prompt) Input(name, msg string, options ...InputOption) (string, error) {
+func (p *Prompt) Input(name, msg string, options ...InputOption) (string, error) {
+	return p.InputContext(context.Background(), name, msg, options...)
+}
+
+// InputContext is like Input but uses ctx to cancel or time out the
+// daemon request.
+func (p *Prompt) InputContext(ctx context.Context, name, msg string, options ...InputOption) (string, error) {
+	definition := newInputDefinition(name, msg, options)
+	if _, err := definition.Compile(); err != nil {
+		return "", err
+	}
+
+	answer, err := p.askValidated(ctx, definition, name, decodeStringAnswer)
+	if err != nil {
+		return "", err
+	}
+
+	return answer.(string), nil
+}
+
+// newInputDefinition builds the input prompt definition sent to the daemon
+func newInputDefinition(name, msg string, options []InputOption) daemon.InputPromptBody {
 	definition := daemon.InputPromptBody{
 		PromptEnvelope: daemon.PromptEnvelope{
 			Name:       name,
@@ -71,18 +170,7 @@ func (*Prompt) Input(name, msg string, options ...InputOption) (string, error) {
 		option(&definition)
 	}
 
-	body, err := daemon.AsyncRequest("prompt", definition, "POST")
-	if err != nil {
-		return "", err
-	}
-
-	if value, ok := body[name]; ok {
-		if str, ok := value.(string); ok {
-			return str, nil
-		}
-		return "", fmt.Errorf("Daemon returned non-string value %v", value)
-	}
-	return "", fmt.Errorf("Daemon returned incorrect JSON %v", body)
+	return definition
 }
 
 // NumberOption is a functional option type for the Number method.
@@ -118,10 +206,23 @@ func OptNumberMinimum(minimumValue int) NumberOption {
 	}
 }
 
+// OptNumberValidate sets a function that checks the answer to the number
+// prompt; see OptInputValidate.
+func OptNumberValidate(validate func(int) error) NumberOption {
+	return func(definition *daemon.NumberPromptBody) {
+		definition.Validate = func(answer interface{}) error {
+			value, _ := answer.(int)
+			return validate(value)
+		}
+	}
+}
+
 // Number presents a prompt for a numeric value to the interface
 // (i.e. terminal or slack).
 //
-// The method returns the user's response as int.
+// The method returns the user's response as int. Fractional answers and
+// answers that do not fit in an int are rejected with an error; use Float,
+// Int64 or Uint64 for those.
 //
 // Example:
 //
@@ -135,7 +236,25 @@ func OptNumberMinimum(minimumValue int) NumberOption {
 //
 // Output:
 // 7
-func (*Prompt) Number(name, msg string, options ...NumberOption) (int, error) {
+func (p *Prompt) Number(name, msg string, options ...NumberOption) (int, error) {
+	return p.NumberContext(context.Background(), name, msg, options...)
+}
+
+// NumberContext is like Number but uses ctx to cancel or time out the
+// daemon request.
+func (p *Prompt) NumberContext(ctx context.Context, name, msg string, options ...NumberOption) (int, error) {
+	definition := newNumberDefinition(name, msg, options)
+
+	answer, err := p.askValidated(ctx, definition, name, decodeNumberAnswer)
+	if err != nil {
+		return 0, err
+	}
+
+	return answer.(int), nil
+}
+
+// newNumberDefinition builds the number prompt definition sent to the daemon
+func newNumberDefinition(name, msg string, options []NumberOption) daemon.NumberPromptBody {
 	definition := daemon.NumberPromptBody{
 		PromptEnvelope: daemon.PromptEnvelope{
 			Name:    name,
@@ -146,18 +265,268 @@ func (*Prompt) Number(name, msg string, options ...NumberOption) (int, error) {
 		option(&definition)
 	}
 
-	body, err := daemon.AsyncRequest("prompt", definition, "POST")
+	return definition
+}
+
+// FloatOption is an option for the Float prompt function
+type FloatOption func(*daemon.NumericPromptBody)
+
+// OptFloatFlag sets the flag value for the float prompt.
+//
+// The flag value is used to match command line arguments to prompts.
+func OptFloatFlag(flag string) FloatOption {
+	return func(definition *daemon.NumericPromptBody) {
+		definition.Flag = flag
+	}
+}
+
+func OptFloatDefault(defaultValue float64) FloatOption {
+	return func(definition *daemon.NumericPromptBody) {
+		definition.Default = formatFloat(defaultValue)
+	}
+}
+
+func OptFloatMaximum(maximumValue float64) FloatOption {
+	return func(definition *daemon.NumericPromptBody) {
+		definition.Maximum = formatFloat(maximumValue)
+	}
+}
+
+func OptFloatMinimum(minimumValue float64) FloatOption {
+	return func(definition *daemon.NumericPromptBody) {
+		definition.Minimum = formatFloat(minimumValue)
+	}
+}
+
+// OptFloatStep sets the step between accepted answers to the float prompt,
+// counted from the minimum if one is set and from 0 otherwise, e.g. 0.25.
+func OptFloatStep(step float64) FloatOption {
+	return func(definition *daemon.NumericPromptBody) {
+		definition.Step = formatFloat(step)
+	}
+}
+
+// OptFloatPrecision sets the number of decimal places accepted in answers
+// to the float prompt.
+func OptFloatPrecision(precision int) FloatOption {
+	return func(definition *daemon.NumericPromptBody) {
+		definition.Precision = &precision
+	}
+}
+
+// OptFloatValidate sets a function that checks the answer to the float
+// prompt; see OptInputValidate.
+func OptFloatValidate(validate func(float64) error) FloatOption {
+	return func(definition *daemon.NumericPromptBody) {
+		definition.Validate = func(answer interface{}) error {
+			value, _ := answer.(float64)
+			return validate(value)
+		}
+	}
+}
+
+// Float presents a prompt for a fractional numeric value to the interface
+// (i.e. terminal or slack).
+//
+// Answers outside the minimum and maximum, off the step or with more
+// decimal places than the precision are rejected, and the question is asked
+// again as with OptFloatValidate. It fails with an error matching
+// ErrUnsupported if the daemon does not support FeatureNumberFloat.
+//
+// The method returns the user's response as float64.
+//
+// Example:
+//
+//  p := ctoai.NewPrompt()
+//  resp, err := p.Float("cpu", "How many CPUs per replica?", OptFloatDefault(0.5), OptFloatMinimum(0.25), OptFloatStep(0.25)) // user responds with 1.5
+//  if err != nil {
+//      panic(err)
+//  }
+//
+//  fmt.Println(resp)
+//
+// Output:
+// 1.5
+func (p *Prompt) Float(name, msg string, options ...FloatOption) (float64, error) {
+	return p.FloatContext(context.Background(), name, msg, options...)
+}
+
+// FloatContext is like Float but uses ctx to cancel or time out the daemon
+// request.
+func (p *Prompt) FloatContext(ctx context.Context, name, msg string, options ...FloatOption) (float64, error) {
+	definition := newNumericDefinition(name, msg, true)
+	for _, option := range options {
+		option(&definition)
+	}
+
+	answer, err := p.askValidated(ctx, definition, name, decodeFloatAnswer)
 	if err != nil {
 		return 0, err
 	}
 
-	if value, ok := body[name]; ok {
-		if num, ok := value.(float64); ok {
-			return int(num), nil
+	return answer.(float64), nil
+}
+
+// Int64Option is an option for the Int64 prompt function
+type Int64Option func(*daemon.NumericPromptBody)
+
+// OptInt64Flag sets the flag value for the int64 prompt.
+//
+// The flag value is used to match command line arguments to prompts.
+func OptInt64Flag(flag string) Int64Option {
+	return func(definition *daemon.NumericPromptBody) {
+		definition.Flag = flag
+	}
+}
+
+func OptInt64Default(defaultValue int64) Int64Option {
+	return func(definition *daemon.NumericPromptBody) {
+		definition.Default = json.Number(strconv.FormatInt(defaultValue, 10))
+	}
+}
+
+func OptInt64Maximum(maximumValue int64) Int64Option {
+	return func(definition *daemon.NumericPromptBody) {
+		definition.Maximum = json.Number(strconv.FormatInt(maximumValue, 10))
+	}
+}
+
+func OptInt64Minimum(minimumValue int64) Int64Option {
+	return func(definition *daemon.NumericPromptBody) {
+		definition.Minimum = json.Number(strconv.FormatInt(minimumValue, 10))
+	}
+}
+
+// OptInt64Validate sets a function that checks the answer to the int64
+// prompt; see OptInputValidate.
+func OptInt64Validate(validate func(int64) error) Int64Option {
+	return func(definition *daemon.NumericPromptBody) {
+		definition.Validate = func(answer interface{}) error {
+			value, _ := answer.(int64)
+			return validate(value)
+		}
+	}
+}
+
+// Int64 presents a prompt for a whole number to the interface (i.e.
+// terminal or slack), like Number but with 64-bit bounds and answer.
+//
+// The answer is decoded exactly, even beyond the 2^53 limit of float64.
+// Fractional answers and answers that do not fit in an int64 are rejected
+// with an error.
+//
+// Example:
+//
+//  p := ctoai.NewPrompt()
+//  resp, err := p.Int64("id", "Which account ID?", OptInt64Minimum(1)) // user responds with 9007199254740993
+//  if err != nil {
+//      panic(err)
+//  }
+//
+//  fmt.Println(resp)
+//
+// Output:
+// 9007199254740993
+func (p *Prompt) Int64(name, msg string, options ...Int64Option) (int64, error) {
+	return p.Int64Context(context.Background(), name, msg, options...)
+}
+
+// Int64Context is like Int64 but uses ctx to cancel or time out the daemon
+// request.
+func (p *Prompt) Int64Context(ctx context.Context, name, msg string, options ...Int64Option) (int64, error) {
+	definition := newNumericDefinition(name, msg, false)
+	for _, option := range options {
+		option(&definition)
+	}
+
+	answer, err := p.askValidated(ctx, definition, name, decodeInt64Answer)
+	if err != nil {
+		return 0, err
+	}
+
+	return answer.(int64), nil
+}
+
+// Uint64Option is an option for the Uint64 prompt function
+type Uint64Option func(*daemon.NumericPromptBody)
+
+// OptUint64Flag sets the flag value for the uint64 prompt.
+//
+// The flag value is used to match command line arguments to prompts.
+func OptUint64Flag(flag string) Uint64Option {
+	return func(definition *daemon.NumericPromptBody) {
+		definition.Flag = flag
+	}
+}
+
+func OptUint64Default(defaultValue uint64) Uint64Option {
+	return func(definition *daemon.NumericPromptBody) {
+		definition.Default = json.Number(strconv.FormatUint(defaultValue, 10))
+	}
+}
+
+func OptUint64Maximum(maximumValue uint64) Uint64Option {
+	return func(definition *daemon.NumericPromptBody) {
+		definition.Maximum = json.Number(strconv.FormatUint(maximumValue, 10))
+	}
+}
+
+func OptUint64Minimum(minimumValue uint64) Uint64Option {
+	return func(definition *daemon.NumericPromptBody) {
+		definition.Minimum = json.Number(strconv.FormatUint(minimumValue, 10))
+	}
+}
+
+// OptUint64Validate sets a function that checks the answer to the uint64
+// prompt; see OptInputValidate.
+func OptUint64Validate(validate func(uint64) error) Uint64Option {
+	return func(definition *daemon.NumericPromptBody) {
+		definition.Validate = func(answer interface{}) error {
+			value, _ := answer.(uint64)
+			return validate(value)
 		}
-		return 0, fmt.Errorf("Daemon returned non-numeric value %v", value)
 	}
-	return 0, fmt.Errorf("Daemon returned incorrect JSON %v", body)
+}
+
+// Uint64 presents a prompt for a non-negative whole number to the
+// interface (i.e. terminal or slack); see Int64.
+func (p *Prompt) Uint64(name, msg string, options ...Uint64Option) (uint64, error) {
+	return p.Uint64Context(context.Background(), name, msg, options...)
+}
+
+// Uint64Context is like Uint64 but uses ctx to cancel or time out the
+// daemon request.
+func (p *Prompt) Uint64Context(ctx context.Context, name, msg string, options ...Uint64Option) (uint64, error) {
+	definition := newNumericDefinition(name, msg, false)
+	definition.Minimum = "0"
+	for _, option := range options {
+		option(&definition)
+	}
+
+	answer, err := p.askValidated(ctx, definition, name, decodeUint64Answer)
+	if err != nil {
+		return 0, err
+	}
+
+	return answer.(uint64), nil
+}
+
+// newNumericDefinition builds the definition of a float, int64 or uint64
+// prompt sent to the daemon, before its options are applied
+func newNumericDefinition(name, msg string, float bool) daemon.NumericPromptBody {
+	return daemon.NumericPromptBody{
+		PromptEnvelope: daemon.PromptEnvelope{
+			Name:       name,
+			PromptType: "number",
+			Message:    msg,
+		},
+		Float: float,
+	}
+}
+
+// formatFloat formats f as the shortest JSON number that decodes to it
+func formatFloat(f float64) json.Number {
+	return json.Number(strconv.FormatFloat(f, 'g', -1, 64))
 }
 
 type SecretOption func(*daemon.SecretPromptBody)
@@ -171,6 +540,18 @@ func OptSecretFlag(flag string) SecretOption {
 	}
 }
 
+// OptSecretValidate sets a function that checks the answer to the secret
+// prompt; see OptInputValidate. The prompt is asked again without a
+// default, so that the rejected secret is not shown.
+func OptSecretValidate(validate func(string) error) SecretOption {
+	return func(definition *daemon.SecretPromptBody) {
+		definition.Validate = func(answer interface{}) error {
+			value, _ := answer.(string)
+			return validate(value)
+		}
+	}
+}
+
 // Secret presents an input prompt for secrets in the interface
 // (i.e. terminal or slack).
 //
@@ -196,7 +577,25 @@ func OptSecretFlag(flag string) SecretOption {
 //
 // Output:
 // 1234asdf
-func (*Prompt) Secret(name, msg string, options ...SecretOption) (string, error) {
+func (p *Prompt) Secret(name, msg string, options ...SecretOption) (string, error) {
+	return p.SecretContext(context.Background(), name, msg, options...)
+}
+
+// SecretContext is like Secret but uses ctx to cancel or time out the
+// daemon request.
+func (p *Prompt) SecretContext(ctx context.Context, name, msg string, options ...SecretOption) (string, error) {
+	definition := newSecretDefinition(name, msg, options)
+
+	answer, err := p.askValidated(ctx, definition, name, decodeStringAnswer)
+	if err != nil {
+		return "", err
+	}
+
+	return answer.(string), nil
+}
+
+// newSecretDefinition builds the secret prompt definition sent to the daemon
+func newSecretDefinition(name, msg string, options []SecretOption) daemon.SecretPromptBody {
 	definition := daemon.SecretPromptBody{
 		Name:       name,
 		PromptType: "secret",
@@ -206,18 +605,7 @@ func (*Prompt) Secret(name, msg string, options ...SecretOption) (string, error)
 		option(&definition)
 	}
 
-	body, err := daemon.AsyncRequest("prompt", definition, "POST")
-	if err != nil {
-		return "", err
-	}
-
-	if value, ok := body[name]; ok {
-		if str, ok := value.(string); ok {
-			return str, nil
-		}
-		return "", fmt.Errorf("Daemon returned non-string value %v", value)
-	}
-	return "", fmt.Errorf("Daemon returned incorrect JSON %v", body)
+	return definition
 }
 
 type PasswordOption func(*daemon.PasswordPromptBody)
@@ -237,6 +625,18 @@ func OptPasswordConfirm(confirm bool) PasswordOption {
 	}
 }
 
+// OptPasswordValidate sets a function that checks the answer to the password
+// prompt; see OptInputValidate. The prompt is asked again without a
+// default, so that the rejected password is not shown.
+func OptPasswordValidate(validate func(string) error) PasswordOption {
+	return func(definition *daemon.PasswordPromptBody) {
+		definition.Validate = func(answer interface{}) error {
+			value, _ := answer.(string)
+			return validate(value)
+		}
+	}
+}
+
 // Password presents an input prompt for passwords in the interface
 // (i.e. terminal or slack).
 //
@@ -262,7 +662,25 @@ func OptPasswordConfirm(confirm bool) PasswordOption {
 //
 // Output:
 // 1234asdf
-func (*Prompt) Password(name, msg string, options ...PasswordOption) (string, error) {
+func (p *Prompt) Password(name, msg string, options ...PasswordOption) (string, error) {
+	return p.PasswordContext(context.Background(), name, msg, options...)
+}
+
+// PasswordContext is like Password but uses ctx to cancel or time out the
+// daemon request.
+func (p *Prompt) PasswordContext(ctx context.Context, name, msg string, options ...PasswordOption) (string, error) {
+	definition := newPasswordDefinition(name, msg, options)
+
+	answer, err := p.askValidated(ctx, definition, name, decodeStringAnswer)
+	if err != nil {
+		return "", err
+	}
+
+	return answer.(string), nil
+}
+
+// newPasswordDefinition builds the password prompt definition sent to the daemon
+func newPasswordDefinition(name, msg string, options []PasswordOption) daemon.PasswordPromptBody {
 	definition := daemon.PasswordPromptBody{
 		PromptEnvelope: daemon.PromptEnvelope{
 			Name:       name,
@@ -274,18 +692,7 @@ func (*Prompt) Password(name, msg string, options ...PasswordOption) (string, er
 		option(&definition)
 	}
 
-	body, err := daemon.AsyncRequest("prompt", definition, "POST")
-	if err != nil {
-		return "", err
-	}
-
-	if value, ok := body[name]; ok {
-		if str, ok := value.(string); ok {
-			return str, nil
-		}
-		return "", fmt.Errorf("Daemon returned non-string value %v", value)
-	}
-	return "", fmt.Errorf("Daemon returned incorrect JSON %v", body)
+	return definition
 }
 
 type ConfirmOption func(*daemon.ConfirmPromptBody)
@@ -305,6 +712,17 @@ func OptConfirmDefault(defaultValue bool) ConfirmOption {
 	}
 }
 
+// OptConfirmValidate sets a function that checks the answer to the confirm
+// prompt; see OptInputValidate.
+func OptConfirmValidate(validate func(bool) error) ConfirmOption {
+	return func(definition *daemon.ConfirmPromptBody) {
+		definition.Validate = func(answer interface{}) error {
+			value, _ := answer.(bool)
+			return validate(value)
+		}
+	}
+}
+
 // Confirm presents a yes/no question to the user in the interface
 // (i.e. terminal or slack).
 //
@@ -320,7 +738,25 @@ func OptConfirmDefault(defaultValue bool) ConfirmOption {
 //
 // Output:
 // true
-func (*Prompt) Confirm(name, msg string, options ...ConfirmOption) (bool, error) {
+func (p *Prompt) Confirm(name, msg string, options ...ConfirmOption) (bool, error) {
+	return p.ConfirmContext(context.Background(), name, msg, options...)
+}
+
+// ConfirmContext is like Confirm but uses ctx to cancel or time out the
+// daemon request.
+func (p *Prompt) ConfirmContext(ctx context.Context, name, msg string, options ...ConfirmOption) (bool, error) {
+	definition := newConfirmDefinition(name, msg, options)
+
+	answer, err := p.askValidated(ctx, definition, name, decodeBoolAnswer)
+	if err != nil {
+		return false, err
+	}
+
+	return answer.(bool), nil
+}
+
+// newConfirmDefinition builds the confirm prompt definition sent to the daemon
+func newConfirmDefinition(name, msg string, options []ConfirmOption) daemon.ConfirmPromptBody {
 	definition := daemon.ConfirmPromptBody{
 		PromptEnvelope: daemon.PromptEnvelope{
 			Name:       name,
@@ -332,18 +768,7 @@ func (*Prompt) Confirm(name, msg string, options ...ConfirmOption) (bool, error)
 		option(&definition)
 	}
 
-	body, err := daemon.AsyncRequest("prompt", definition, "POST")
-	if err != nil {
-		return false, err
-	}
-
-	if value, ok := body[name]; ok {
-		if b, ok := value.(bool); ok {
-			return b, nil
-		}
-		return false, fmt.Errorf("Daemon returned non-boolean value %v", value)
-	}
-	return false, fmt.Errorf("Daemon returned incorrect JSON %v", body)
+	return definition
 }
 
 type ListOption func(*daemon.ListPromptBody)
@@ -383,6 +808,17 @@ func OptListAutocomplete(autocomplete bool) ListOption {
 	}
 }
 
+// OptListValidate sets a function that checks the answer to the list
+// prompt; see OptInputValidate.
+func OptListValidate(validate func(string) error) ListOption {
+	return func(definition *daemon.ListPromptBody) {
+		definition.Validate = func(answer interface{}) error {
+			value, _ := answer.(string)
+			return validate(value)
+		}
+	}
+}
+
 // List presents a list of options to the user to select one item from in
 // the interface (i.e. terminal or slack).
 //
@@ -400,7 +836,25 @@ func OptListAutocomplete(autocomplete bool) ListOption {
 //
 // Output:
 // Azure
-func (*Prompt) List(name, msg string, choices []string, options ...ListOption) (string, error) {
+func (p *Prompt) List(name, msg string, choices []string, options ...ListOption) (string, error) {
+	return p.ListContext(context.Background(), name, msg, choices, options...)
+}
+
+// ListContext is like List but uses ctx to cancel or time out the
+// daemon request.
+func (p *Prompt) ListContext(ctx context.Context, name, msg string, choices []string, options ...ListOption) (string, error) {
+	definition := newListDefinition(name, msg, choices, options)
+
+	answer, err := p.askValidated(ctx, definition, name, decodeStringAnswer)
+	if err != nil {
+		return "", err
+	}
+
+	return answer.(string), nil
+}
+
+// newListDefinition builds the list prompt definition sent to the daemon
+func newListDefinition(name, msg string, choices []string, options []ListOption) daemon.ListPromptBody {
 	definition := daemon.ListPromptBody{
 		PromptEnvelope: daemon.PromptEnvelope{
 			Name:       name,
@@ -413,18 +867,7 @@ func (*Prompt) List(name, msg string, choices []string, options ...ListOption) (
 		option(&definition)
 	}
 
-	body, err := daemon.AsyncRequest("prompt", definition, "POST")
-	if err != nil {
-		return "", err
-	}
-
-	if value, ok := body[name]; ok {
-		if b, ok := value.(string); ok {
-			return b, nil
-		}
-		return "", fmt.Errorf("Daemon returned non-string value %v", value)
-	}
-	return "", fmt.Errorf("Daemon returned incorrect JSON %v", body)
+	return definition
 }
 
 type CheckboxOption func(*daemon.CheckboxPromptBody)
@@ -454,6 +897,44 @@ func OptCheckboxDefaultIndex(defaultIndexes []int) CheckboxOption {
 	}
 }
 
+// OptCheckboxMin sets the minimum number of choices the user must select
+// in the checkbox prompt. The daemon enforces it, and the SDK asks again if
+// the answer selects fewer choices.
+func OptCheckboxMin(min int) CheckboxOption {
+	return func(definition *daemon.CheckboxPromptBody) {
+		definition.MinSelected = min
+	}
+}
+
+// OptCheckboxMax sets the maximum number of choices the user can select in
+// the checkbox prompt; see OptCheckboxMin.
+func OptCheckboxMax(max int) CheckboxOption {
+	return func(definition *daemon.CheckboxPromptBody) {
+		definition.MaxSelected = max
+	}
+}
+
+// OptCheckboxRequired makes the user select at least one choice in the
+// checkbox prompt, unless OptCheckboxMin requires more.
+func OptCheckboxRequired() CheckboxOption {
+	return func(definition *daemon.CheckboxPromptBody) {
+		if definition.MinSelected < 1 {
+			definition.MinSelected = 1
+		}
+	}
+}
+
+// OptCheckboxValidate sets a function that checks the answer to the checkbox
+// prompt; see OptInputValidate.
+func OptCheckboxValidate(validate func([]string) error) CheckboxOption {
+	return func(definition *daemon.CheckboxPromptBody) {
+		definition.Validate = func(answer interface{}) error {
+			value, _ := answer.([]string)
+			return validate(value)
+		}
+	}
+}
+
 // Checkbox presents a list of options to the user, who can select multiple
 // items in the interface (i.e. terminal or slack).
 //
@@ -471,7 +952,25 @@ func OptCheckboxDefaultIndex(defaultIndexes []int) CheckboxOption {
 //
 // Output:
 // Lua
-func (*Prompt) Checkbox(name, msg string, choices []string, options ...CheckboxOption) ([]string, error) {
+func (p *Prompt) Checkbox(name, msg string, choices []string, options ...CheckboxOption) ([]string, error) {
+	return p.CheckboxContext(context.Background(), name, msg, choices, options...)
+}
+
+// CheckboxContext is like Checkbox but uses ctx to cancel or time out the
+// daemon request.
+func (p *Prompt) CheckboxContext(ctx context.Context, name, msg string, choices []string, options ...CheckboxOption) ([]string, error) {
+	definition := newCheckboxDefinition(name, msg, choices, options)
+
+	answer, err := p.askValidated(ctx, definition, name, decodeStringsAnswer)
+	if err != nil {
+		return nil, err
+	}
+
+	return answer.([]string), nil
+}
+
+// newCheckboxDefinition builds the checkbox prompt definition sent to the daemon
+func newCheckboxDefinition(name, msg string, choices []string, options []CheckboxOption) daemon.CheckboxPromptBody {
 	definition := daemon.CheckboxPromptBody{
 		PromptEnvelope: daemon.PromptEnvelope{
 			Name:       name,
@@ -484,26 +983,7 @@ func (*Prompt) Checkbox(name, msg string, choices []string, options ...CheckboxO
 		option(&definition)
 	}
 
-	body, err := daemon.AsyncRequest("prompt", definition, "POST")
-	if err != nil {
-		return nil, err
-	}
-
-	if value, ok := body[name]; ok {
-		if values, ok := value.([]interface{}); ok {
-			strings := make([]string, len(values))
-			for i, v := range values {
-				if s, ok := v.(string); ok {
-					strings[i] = s
-				} else {
-					return nil, fmt.Errorf("Daemon returned non-string value %v", v)
-				}
-			}
-			return strings, nil
-		}
-		return nil, fmt.Errorf("Daemon returned non-array value %v", value)
-	}
-	return nil, fmt.Errorf("Daemon returned incorrect JSON %v", body)
+	return definition
 }
 
 // EditorOption is an option for the Editor prompt function
@@ -524,6 +1004,17 @@ func OptEditorDefault(defaultValue string) EditorOption {
 	}
 }
 
+// OptEditorValidate sets a function that checks the answer to the editor
+// prompt; see OptInputValidate.
+func OptEditorValidate(validate func(string) error) EditorOption {
+	return func(definition *daemon.EditorPromptBody) {
+		definition.Validate = func(answer interface{}) error {
+			value, _ := answer.(string)
+			return validate(value)
+		}
+	}
+}
+
 // Editor presets a prompt requesting a multi-line response from the
 // user. If used in a terminal interface, the nano editor will be
 // presented.
@@ -546,7 +1037,25 @@ func OptEditorDefault(defaultValue string) EditorOption {
 //
 // Output:
 // [Nano will be brought up with the template in the editor]
-func (*Prompt) Editor(name, msg string, options ...EditorOption) (string, error) {
+func (p *Prompt) Editor(name, msg string, options ...EditorOption) (string, error) {
+	return p.EditorContext(context.Background(), name, msg, options...)
+}
+
+// EditorContext is like Editor but uses ctx to cancel or time out the
+// daemon request.
+func (p *Prompt) EditorContext(ctx context.Context, name, msg string, options ...EditorOption) (string, error) {
+	definition := newEditorDefinition(name, msg, options)
+
+	answer, err := p.askValidated(ctx, definition, name, decodeStringAnswer)
+	if err != nil {
+		return "", err
+	}
+
+	return answer.(string), nil
+}
+
+// newEditorDefinition builds the editor prompt definition sent to the daemon
+func newEditorDefinition(name, msg string, options []EditorOption) daemon.EditorPromptBody {
 	definition := daemon.EditorPromptBody{
 		PromptEnvelope: daemon.PromptEnvelope{
 			Name:       name,
@@ -558,18 +1067,7 @@ func (*Prompt) Editor(name, msg string, options ...EditorOption) (string, error)
 		option(&definition)
 	}
 
-	body, err := daemon.AsyncRequest("prompt", definition, "POST")
-	if err != nil {
-		return "", err
-	}
-
-	if value, ok := body[name]; ok {
-		if str, ok := value.(string); ok {
-			return str, nil
-		}
-		return "", fmt.Errorf("Daemon returned non-string value %v", value)
-	}
-	return "", fmt.Errorf("Daemon returned incorrect JSON %v", body)
+	return definition
 }
 
 // DatetimeOption is an option for the Datetime prompt function
@@ -614,12 +1112,24 @@ func OptDatetimeMinimum(minimumValue time.Time) DatetimeOption {
 	}
 }
 
+// OptDatetimeValidate sets a function that checks the answer to the datetime
+// prompt; see OptInputValidate.
+func OptDatetimeValidate(validate func(time.Time) error) DatetimeOption {
+	return func(definition *daemon.DatetimePromptBody) {
+		definition.Validate = func(answer interface{}) error {
+			value, _ := answer.(time.Time)
+			return validate(value)
+		}
+	}
+}
+
 // Datetime presents a date picker to the user that allows them to
 // select a date and/or time.
 //
 // The method returns the user's response as a time.Time type.
 //
 // Example:
+//
 //  import "time"
 //
 //  p := ctoai.NewPrompt()
@@ -632,7 +1142,25 @@ func OptDatetimeMinimum(minimumValue time.Time) DatetimeOption {
 //
 // Output:
 // [the output will equal time.Now() in 2006-01-02 15:04:05 format]
-func (*Prompt) Datetime(name, msg string, options ...DatetimeOption) (time.Time, error) {
+func (p *Prompt) Datetime(name, msg string, options ...DatetimeOption) (time.Time, error) {
+	return p.DatetimeContext(context.Background(), name, msg, options...)
+}
+
+// DatetimeContext is like Datetime but uses ctx to cancel or time out the
+// daemon request.
+func (p *Prompt) DatetimeContext(ctx context.Context, name, msg string, options ...DatetimeOption) (time.Time, error) {
+	definition := newDatetimeDefinition(name, msg, options)
+
+	answer, err := p.askValidated(ctx, definition, name, decodeDatetimeAnswer)
+	if err != nil {
+		return time.Unix(0, 0), err
+	}
+
+	return answer.(time.Time), nil
+}
+
+// newDatetimeDefinition builds the datetime prompt definition sent to the daemon
+func newDatetimeDefinition(name, msg string, options []DatetimeOption) daemon.DatetimePromptBody {
 	definition := daemon.DatetimePromptBody{
 		PromptEnvelope: daemon.PromptEnvelope{
 			Name:       name,
@@ -645,11 +1173,240 @@ func (*Prompt) Datetime(name, msg string, options ...DatetimeOption) (time.Time,
 		option(&definition)
 	}
 
-	body, err := daemon.AsyncRequest("prompt", definition, "POST")
+	return definition
+}
+
+// DurationOption is an option for the Duration prompt function
+type DurationOption func(*daemon.DurationPromptBody)
+
+// OptDurationFlag sets the flag value for the duration prompt.
+//
+// The flag value is used to match command line arguments to prompts.
+func OptDurationFlag(flag string) DurationOption {
+	return func(definition *daemon.DurationPromptBody) {
+		definition.Flag = flag
+	}
+}
+
+// OptDurationDefault sets the default answer of the duration prompt
+func OptDurationDefault(defaultValue time.Duration) DurationOption {
+	return func(definition *daemon.DurationPromptBody) {
+		definition.Default = defaultValue.String()
+	}
+}
+
+// OptDurationMinimum sets the shortest duration accepted by the duration
+// prompt. Without a minimum, negative durations are rejected; a negative
+// minimum allows them.
+func OptDurationMinimum(minimumValue time.Duration) DurationOption {
+	return func(definition *daemon.DurationPromptBody) {
+		definition.Minimum = minimumValue.String()
+	}
+}
+
+// OptDurationMaximum sets the longest duration accepted by the duration
+// prompt
+func OptDurationMaximum(maximumValue time.Duration) DurationOption {
+	return func(definition *daemon.DurationPromptBody) {
+		definition.Maximum = maximumValue.String()
+	}
+}
+
+// OptDurationValidate sets a function that checks the answer to the
+// duration prompt; see OptInputValidate.
+func OptDurationValidate(validate func(time.Duration) error) DurationOption {
+	return func(definition *daemon.DurationPromptBody) {
+		definition.Validate = func(answer interface{}) error {
+			value, _ := answer.(time.Duration)
+			return validate(value)
+		}
+	}
+}
+
+// Duration asks the user for a length of time, written either like
+// "1h30m" as in Go, or in words like "90 minutes" or "2 days and 4 hours".
+// Days are 24 hours and weeks are 7 days long.
+//
+// Answers that cannot be parsed or fall outside the bounds set with
+// OptDurationMinimum and OptDurationMaximum are rejected, and the prompt is
+// asked again. Daemons that do not support duration prompts are asked an
+// input prompt whose answer the SDK parses.
+//
+// Example:
+//
+//  p := ctoai.NewPrompt()
+//  resp, err := p.Duration("ttl", "How long should the preview environment be kept?", ctoai.OptDurationDefault(24*time.Hour), ctoai.OptDurationMaximum(14*24*time.Hour)) // user types 2 days
+//  if err != nil {
+//      panic(err)
+//  }
+//
+//  fmt.Println(resp)
+//
+// Output:
+// 48h0m0s
+func (p *Prompt) Duration(name, msg string, options ...DurationOption) (time.Duration, error) {
+	return p.DurationContext(context.Background(), name, msg, options...)
+}
+
+// DurationContext is like Duration but uses ctx to cancel or time out the
+// daemon request.
+func (p *Prompt) DurationContext(ctx context.Context, name, msg string, options ...DurationOption) (time.Duration, error) {
+	definition := newDurationDefinition(name, msg, options)
+
+	answer, err := p.askValidated(ctx, definition, name, decodeDurationAnswer)
 	if err != nil {
-		return time.Unix(0, 0), err
+		return 0, err
+	}
+
+	return answer.(time.Duration), nil
+}
+
+// newDurationDefinition builds the duration prompt definition sent to the daemon
+func newDurationDefinition(name, msg string, options []DurationOption) daemon.DurationPromptBody {
+	definition := daemon.DurationPromptBody{
+		PromptEnvelope: daemon.PromptEnvelope{
+			Name:       name,
+			PromptType: "duration",
+			Message:    msg,
+		},
 	}
+	for _, option := range options {
+		option(&definition)
+	}
+
+	return definition
+}
+
+// The decode functions extract the typed answer to the prompt called name
+// from the daemon's reply.
+
+func decodeString(body map[string]interface{}, name string) (string, error) {
+	if value, ok := body[name]; ok {
+		if str, ok := value.(string); ok {
+			return str, nil
+		}
+		return "", fmt.Errorf("Daemon returned non-string value %v", value)
+	}
+	return "", fmt.Errorf("Daemon returned incorrect JSON %v", body)
+}
+
+// Bounds of the int type
+const (
+	maxInt = int(^uint(0) >> 1)
+	minInt = -maxInt - 1
+)
+
+var maxUint64 = new(big.Int).SetUint64(math.MaxUint64)
+
+// decodeRat extracts a numeric answer exactly. Replies from the daemon
+// hold json.Number values; in-memory handlers may also return floats and
+// integers.
+func decodeRat(body map[string]interface{}, name string) (*big.Rat, error) {
+	value, ok := body[name]
+	if !ok {
+		return nil, fmt.Errorf("Daemon returned incorrect JSON %v", body)
+	}
+
+	var text string
+	switch v := value.(type) {
+	case json.Number:
+		text = v.String()
+	case float64:
+		text = strconv.FormatFloat(v, 'g', -1, 64)
+	case int:
+		text = strconv.Itoa(v)
+	case int64:
+		text = strconv.FormatInt(v, 10)
+	case uint64:
+		text = strconv.FormatUint(v, 10)
+	}
+	if r, ok := new(big.Rat).SetString(text); ok && text != "" {
+		return r, nil
+	}
+	return nil, fmt.Errorf("Daemon returned non-numeric value %v", value)
+}
+
+// decodeInteger extracts a whole numeric answer between min and max
+func decodeInteger(body map[string]interface{}, name string, min, max *big.Int) (*big.Int, error) {
+	r, err := decodeRat(body, name)
+	if err != nil {
+		return nil, err
+	}
+	if !r.IsInt() {
+		return nil, fmt.Errorf("Daemon returned fractional value %v", body[name])
+	}
+	n := r.Num()
+	if n.Cmp(min) < 0 || n.Cmp(max) > 0 {
+		return nil, fmt.Errorf("Daemon returned out of range value %v", body[name])
+	}
+	return n, nil
+}
+
+func decodeNumber(body map[string]interface{}, name string) (int, error) {
+	n, err := decodeInteger(body, name, big.NewInt(int64(minInt)), big.NewInt(int64(maxInt)))
+	if err != nil {
+		return 0, err
+	}
+	return int(n.Int64()), nil
+}
+
+func decodeInt64(body map[string]interface{}, name string) (int64, error) {
+	n, err := decodeInteger(body, name, big.NewInt(math.MinInt64), big.NewInt(math.MaxInt64))
+	if err != nil {
+		return 0, err
+	}
+	return n.Int64(), nil
+}
+
+func decodeUint64(body map[string]interface{}, name string) (uint64, error) {
+	n, err := decodeInteger(body, name, new(big.Int), maxUint64)
+	if err != nil {
+		return 0, err
+	}
+	return n.Uint64(), nil
+}
+
+func decodeFloat(body map[string]interface{}, name string) (float64, error) {
+	r, err := decodeRat(body, name)
+	if err != nil {
+		return 0, err
+	}
+	f, _ := r.Float64()
+	if math.IsInf(f, 0) {
+		return 0, fmt.Errorf("Daemon returned out of range value %v", body[name])
+	}
+	return f, nil
+}
+
+func decodeBool(body map[string]interface{}, name string) (bool, error) {
+	if value, ok := body[name]; ok {
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+		return false, fmt.Errorf("Daemon returned non-boolean value %v", value)
+	}
+	return false, fmt.Errorf("Daemon returned incorrect JSON %v", body)
+}
+
+func decodeStrings(body map[string]interface{}, name string) ([]string, error) {
+	if value, ok := body[name]; ok {
+		if values, ok := value.([]interface{}); ok {
+			strings := make([]string, len(values))
+			for i, v := range values {
+				if s, ok := v.(string); ok {
+					strings[i] = s
+				} else {
+					return nil, fmt.Errorf("Daemon returned non-string value %v", v)
+				}
+			}
+			return strings, nil
+		}
+		return nil, fmt.Errorf("Daemon returned non-array value %v", value)
+	}
+	return nil, fmt.Errorf("Daemon returned incorrect JSON %v", body)
+}
 
+func decodeDatetime(body map[string]interface{}, name string) (time.Time, error) {
 	if value, ok := body[name]; ok {
 		if str, ok := value.(string); ok {
 			t, err := time.Parse(time.RFC3339, str)
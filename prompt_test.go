@@ -1,7 +1,9 @@
 package ctoai
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -104,6 +106,127 @@ func Test_PromptRequest_PromptNumber(t *testing.T) {
 	}
 }
 
+func Test_PromptRequest_PromptInt64(t *testing.T) {
+	expectedBody := map[string]interface{}{
+		"name":    "id",
+		"type":    "number",
+		"message": "Which ID?",
+		"minimum": json.Number("9007199254740993"),
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ValidateRequest(t, r, "/prompt")
+
+		var tmp map[string]interface{}
+		decoder := json.NewDecoder(r.Body)
+		decoder.UseNumber()
+		if err := decoder.Decode(&tmp); err != nil {
+			t.Errorf("Error in decoding response body: %s", err)
+		}
+
+		if !reflect.DeepEqual(tmp, expectedBody) {
+			t.Errorf("Error unexpected request body: %+v", tmp)
+		}
+
+		fmt.Fprintf(w, `{"replyFilename": "/tmp/response-mocktest"}`)
+	}))
+
+	defer ts.Close()
+
+	SetPortVar(t, ts)
+
+	p := NewPrompt()
+	for _, test := range []struct {
+		reply    string
+		expected int64
+		fails    bool
+	}{
+		{reply: `{"id": 9007199254740995}`, expected: 9007199254740995},
+		{reply: `{"id": 9007199254740995.5}`, fails: true},
+		{reply: `{"id": 9223372036854775808}`, fails: true},
+	} {
+		if err := ioutil.WriteFile("/tmp/response-mocktest", []byte(test.reply), 0600); err != nil {
+			t.Fatalf("Error writing reply file: %v", err)
+		}
+
+		output, err := p.Int64("id", "Which ID?", OptInt64Minimum(9007199254740993))
+		if test.fails {
+			if err == nil {
+				t.Errorf("Error expected reply %s to be rejected, got %v", test.reply, output)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Error in prompt request: %v", err)
+		}
+		if output != test.expected {
+			t.Errorf("Error unexpected output: %v", output)
+		}
+	}
+}
+
+func Test_PromptRequest_PromptFloat(t *testing.T) {
+	var definitions []map[string]interface{}
+	var prints []string
+	answers := []interface{}{json.Number("0.3"), json.Number("1.25"), json.Number("1.5")}
+	p := NewPrompt(OptClientHandler(func(ctx context.Context, req *DaemonRequest) (interface{}, error) {
+		bytes, _ := json.Marshal(req.Body)
+		var body map[string]interface{}
+		json.Unmarshal(bytes, &body)
+
+		switch req.Endpoint {
+		case "capabilities":
+			return fullCapabilities, nil
+		case "print":
+			prints = append(prints, body["text"].(string))
+			return nil, nil
+		}
+		definitions = append(definitions, body)
+		answer := answers[0]
+		answers = answers[1:]
+		return map[string]interface{}{"cpu": answer}, nil
+	}))
+
+	output, err := p.Float("cpu", "CPUs?", OptFloatDefault(0.5), OptFloatMinimum(0.5), OptFloatMaximum(4), OptFloatStep(0.5), OptFloatPrecision(1))
+	if err != nil {
+		t.Fatalf("Error in prompt request: %v", err)
+	}
+	if output != 1.5 {
+		t.Errorf("Error unexpected output: %v", output)
+	}
+
+	expectedBody := map[string]interface{}{
+		"name": "cpu", "type": "number", "message": "CPUs?", "float": true,
+		"default": 0.5, "minimum": 0.5, "maximum": float64(4), "step": 0.5, "precision": float64(1),
+	}
+	if !reflect.DeepEqual(definitions[0], expectedBody) {
+		t.Errorf("Error unexpected request body: %+v", definitions[0])
+	}
+	if definitions[1]["default"] != 0.3 || definitions[2]["default"] != 1.25 {
+		t.Errorf("Error expected rejected answers as defaults: %v", definitions)
+	}
+	expectedPrints := []string{"Answer must be at least 0.5", "Answer must be 0.5 plus a multiple of 0.5"}
+	if !reflect.DeepEqual(prints, expectedPrints) {
+		t.Errorf("Error unexpected prints: %v", prints)
+	}
+
+	p = NewPrompt(OptClientHandler(func(ctx context.Context, req *DaemonRequest) (interface{}, error) {
+		return map[string]interface{}{"version": "1", "features": []interface{}{}}, nil
+	}))
+	if _, err := p.Float("cpu", "CPUs?"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Error expected ErrUnsupported without float support, got: %v", err)
+	}
+}
+
+func Test_PromptRequest_PromptNumberFractional(t *testing.T) {
+	p := NewPrompt(OptClientHandler(func(ctx context.Context, req *DaemonRequest) (interface{}, error) {
+		return map[string]interface{}{"count": json.Number("2.5")}, nil
+	}))
+	if output, err := p.Number("count", "How many?"); err == nil {
+		t.Errorf("Error expected a fractional answer to be rejected, got %v", output)
+	}
+}
+
 func Test_PromptRequest_PromptSecret(t *testing.T) {
 	expectedResponse := `{"replyFilename": "/tmp/response-mocktest"}`
 	expectedBody := daemon.SecretPromptBody{
@@ -0,0 +1,269 @@
+package ctoai
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func NewReplyServer(t *testing.T, filename string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ValidateRequest(t, r, "/prompt")
+		fmt.Fprintf(w, `{"replyFilename": %q}`, filename)
+	}))
+}
+
+func Test_Reply_RemovedAfterRead(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sdk-reply")
+	if err != nil {
+		t.Fatalf("Error creating reply dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "response")
+	err = ioutil.WriteFile(filename, []byte(`{"test": "secret"}`), 0600)
+	if err != nil {
+		t.Fatalf("Error writing reply file: %v", err)
+	}
+
+	ts := NewReplyServer(t, filename)
+	defer ts.Close()
+
+	c := NewClient(OptClientPort(ServerPort(t, ts)), OptClientReplyDir(dir))
+	output, err := c.Prompt.Password("test", "what is secret")
+	if err != nil {
+		t.Errorf("Error in prompt request: %v", err)
+	}
+	if output != "secret" {
+		t.Errorf("Error unexpected output: %v", output)
+	}
+
+	if _, err := os.Stat(filename); !os.IsNotExist(err) {
+		t.Errorf("Error reply file was not removed: %v", err)
+	}
+}
+
+func Test_Reply_OutsideReplyDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sdk-reply")
+	if err != nil {
+		t.Fatalf("Error creating reply dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ts := NewReplyServer(t, "/etc/passwd")
+	defer ts.Close()
+
+	c := NewClient(OptClientPort(ServerPort(t, ts)), OptClientReplyDir(dir))
+	_, err = c.Prompt.Input("test", "type test")
+	if !errors.Is(err, ErrUnsafeReplyFile) {
+		t.Errorf("Error expected ErrUnsafeReplyFile, got: %v", err)
+	}
+}
+
+func Test_Reply_WorldWritable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sdk-reply")
+	if err != nil {
+		t.Fatalf("Error creating reply dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "response")
+	err = ioutil.WriteFile(filename, []byte(`{"test": "test"}`), 0600)
+	if err != nil {
+		t.Fatalf("Error writing reply file: %v", err)
+	}
+	if err := os.Chmod(filename, 0666); err != nil {
+		t.Fatalf("Error changing reply file mode: %v", err)
+	}
+
+	ts := NewReplyServer(t, filename)
+	defer ts.Close()
+
+	c := NewClient(OptClientPort(ServerPort(t, ts)), OptClientReplyDir(dir))
+	_, err = c.Prompt.Input("test", "type test")
+	if !errors.Is(err, ErrUnsafeReplyFile) {
+		t.Errorf("Error expected ErrUnsafeReplyFile, got: %v", err)
+	}
+}
+
+func Test_Reply_WaitsForCompleteWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sdk-reply")
+	if err != nil {
+		t.Fatalf("Error creating reply dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "response")
+	lockname := filename + ".lock"
+	err = ioutil.WriteFile(lockname, nil, 0600)
+	if err != nil {
+		t.Fatalf("Error writing lock file: %v", err)
+	}
+	err = ioutil.WriteFile(filename, []byte(`{"test": "te`), 0600)
+	if err != nil {
+		t.Fatalf("Error writing reply file: %v", err)
+	}
+
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		os.Remove(lockname)
+		time.Sleep(30 * time.Millisecond)
+		ioutil.WriteFile(filename, []byte(`{"test": "test"}`), 0600)
+	}()
+
+	ts := NewReplyServer(t, filename)
+	defer ts.Close()
+
+	c := NewClient(OptClientPort(ServerPort(t, ts)), OptClientReplyDir(dir))
+	output, err := c.Prompt.Input("test", "type test")
+	if err != nil {
+		t.Errorf("Error in prompt request: %v", err)
+	}
+	if output != "test" {
+		t.Errorf("Error unexpected output: %v", output)
+	}
+}
+
+func Test_Reply_Timeout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sdk-reply")
+	if err != nil {
+		t.Fatalf("Error creating reply dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ts := NewReplyServer(t, filepath.Join(dir, "never-written"))
+	defer ts.Close()
+
+	c := NewClient(
+		OptClientPort(ServerPort(t, ts)),
+		OptClientReplyDir(dir),
+		OptClientReplyTimeout(50*time.Millisecond),
+	)
+	_, err = c.Prompt.Input("test", "type test")
+	if err == nil {
+		t.Errorf("Error expected timeout waiting for reply file")
+	}
+}
+
+func Test_Reply_MaxSize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sdk-reply")
+	if err != nil {
+		t.Fatalf("Error creating reply dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "response")
+	err = ioutil.WriteFile(filename, []byte(`{"test": "much too long"}`), 0600)
+	if err != nil {
+		t.Fatalf("Error writing reply file: %v", err)
+	}
+
+	ts := NewReplyServer(t, filename)
+	defer ts.Close()
+
+	c := NewClient(OptClientPort(ServerPort(t, ts)), OptClientReplyDir(dir), OptClientReplyMaxSize(8))
+	_, err = c.Prompt.Input("test", "type test")
+	if err == nil {
+		t.Errorf("Error expected reply file to be rejected as too large")
+	}
+}
+
+func Test_Reply_Symlink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sdk-reply")
+	if err != nil {
+		t.Fatalf("Error creating reply dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	target := filepath.Join(dir, "target")
+	err = ioutil.WriteFile(target, []byte(`{"test": "test"}`), 0600)
+	if err != nil {
+		t.Fatalf("Error writing target file: %v", err)
+	}
+	filename := filepath.Join(dir, "response")
+	if err := os.Symlink(target, filename); err != nil {
+		t.Skipf("Error creating symlink: %v", err)
+	}
+
+	ts := NewReplyServer(t, filename)
+	defer ts.Close()
+
+	c := NewClient(OptClientPort(ServerPort(t, ts)), OptClientReplyDir(dir))
+	_, err = c.Prompt.Input("test", "type test")
+	if !errors.Is(err, ErrUnsafeReplyFile) {
+		t.Errorf("Error expected ErrUnsafeReplyFile, got: %v", err)
+	}
+	if contents, _ := ioutil.ReadFile(target); string(contents) != `{"test": "test"}` {
+		t.Errorf("Error symlink target was modified: %q", contents)
+	}
+}
+
+func Test_Reply_OtherOwner(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("changing the owner of the reply file requires root")
+	}
+	dir, err := ioutil.TempDir("", "sdk-reply")
+	if err != nil {
+		t.Fatalf("Error creating reply dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "response")
+	err = ioutil.WriteFile(filename, []byte(`{"test": "test"}`), 0600)
+	if err != nil {
+		t.Fatalf("Error writing reply file: %v", err)
+	}
+	if err := os.Chown(filename, 65534, 65534); err != nil {
+		t.Fatalf("Error changing reply file owner: %v", err)
+	}
+
+	ts := NewReplyServer(t, filename)
+	defer ts.Close()
+
+	c := NewClient(OptClientPort(ServerPort(t, ts)), OptClientReplyDir(dir))
+	_, err = c.Prompt.Input("test", "type test")
+	if !errors.Is(err, ErrUnsafeReplyFile) {
+		t.Errorf("Error expected ErrUnsafeReplyFile, got: %v", err)
+	}
+}
+
+func Test_Reply_ZeroedBeforeRemoval(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sdk-reply")
+	if err != nil {
+		t.Fatalf("Error creating reply dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "response")
+	err = ioutil.WriteFile(filename, []byte(`{"test": "secret"}`), 0600)
+	if err != nil {
+		t.Fatalf("Error writing reply file: %v", err)
+	}
+	// The hard link keeps the contents reachable after the reply is removed
+	link := filepath.Join(dir, "link")
+	if err := os.Link(filename, link); err != nil {
+		t.Skipf("Error creating hard link: %v", err)
+	}
+
+	ts := NewReplyServer(t, filename)
+	defer ts.Close()
+
+	c := NewClient(OptClientPort(ServerPort(t, ts)), OptClientReplyDir(dir))
+	if _, err := c.Prompt.Secret("test", "what is secret"); err != nil {
+		t.Fatalf("Error in prompt request: %v", err)
+	}
+
+	contents, err := ioutil.ReadFile(link)
+	if err != nil {
+		t.Fatalf("Error reading linked reply file: %v", err)
+	}
+	if string(contents) != string(make([]byte, len(`{"test": "secret"}`))) {
+		t.Errorf("Error reply file was not zeroed: %q", contents)
+	}
+}
@@ -0,0 +1,120 @@
+package ctoai
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func testRetryPolicy() RetryPolicy {
+	policy := DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+	policy.MaxBackoff = 10 * time.Millisecond
+	return policy
+}
+
+func Test_Retry_IdempotentServerError(t *testing.T) {
+	attempts := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ValidateRequest(t, r, "/config/get")
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprintf(w, `{"message": "starting"}`)
+			return
+		}
+		fmt.Fprintf(w, `{"value": "config-value"}`)
+	}))
+	defer ts.Close()
+
+	c := NewClient(OptClientPort(ServerPort(t, ts)), OptClientRetry(testRetryPolicy()))
+	output, err := c.Sdk.GetConfig("test-key")
+	if err != nil {
+		t.Errorf("Error in config request: %v", err)
+	}
+	if output != "config-value" {
+		t.Errorf("Error unexpected output: %v", output)
+	}
+	if attempts != 3 {
+		t.Errorf("Error unexpected attempt count: %d", attempts)
+	}
+}
+
+func Test_Retry_NonIdempotentServerError(t *testing.T) {
+	attempts := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ValidateRequest(t, r, "/print")
+		attempts++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	c := NewClient(OptClientPort(ServerPort(t, ts)), OptClientRetry(testRetryPolicy()))
+	if err := c.Ux.Print("test"); err == nil {
+		t.Errorf("Error expected print to fail")
+	}
+	if attempts != 1 {
+		t.Errorf("Error print should not be retried, got %d attempts", attempts)
+	}
+}
+
+func Test_Retry_MaxAttempts(t *testing.T) {
+	attempts := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer ts.Close()
+
+	policy := testRetryPolicy()
+	policy.MaxAttempts = 2
+	c := NewClient(OptClientPort(ServerPort(t, ts)), OptClientRetry(policy))
+	if _, err := c.Sdk.User(); err == nil {
+		t.Errorf("Error expected user request to fail")
+	}
+	if attempts != 2 {
+		t.Errorf("Error unexpected attempt count: %d", attempts)
+	}
+}
+
+func Test_Retry_DaemonStarting(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error reserving port: %v", err)
+	}
+	addr := l.Addr().String()
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ValidateRequest(t, r, "/print")
+	}))
+	started := make(chan bool, 1)
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			t.Errorf("Error listening on reserved port: %v", err)
+			started <- false
+			return
+		}
+		ts.Listener = l
+		ts.Start()
+		started <- true
+	}()
+	defer func() {
+		if <-started {
+			ts.Close()
+		}
+	}()
+
+	policy := testRetryPolicy()
+	policy.MaxAttempts = 20
+	c := NewClient(OptClientPort(port), OptClientRetry(policy))
+	if err := c.Ux.Print("test"); err != nil {
+		t.Errorf("Error printing test value: %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package ctoai
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -16,10 +17,12 @@ func getenv(name, fallback string) string {
 }
 
 // Sdk is the object that contains the SDK methods
-type Sdk struct{}
+type Sdk struct {
+	transport *daemon.Client
+}
 
-func NewSdk() *Sdk {
-	return &Sdk{}
+func NewSdk(options ...ClientOption) *Sdk {
+	return &Sdk{transport: newTransport(newClientOptions(options))}
 }
 
 // GetHostOS returns the current host OS.
@@ -39,34 +42,82 @@ func (*Sdk) HomeDir() string {
 
 // GetStatePath returns the path to the state directory (local to this particular workflow)
 // DEPRECATED: use `HomeDir` instead.
-func (*Sdk) GetStatePath() string {
+func (s *Sdk) GetStatePath() string {
+	path, err := s.StatePath()
+	if err != nil {
+		panic(err.Error())
+	}
+	return path
+}
+
+// StatePath is like GetStatePath but returns ErrStateDirNotFound instead of
+// panicking.
+// DEPRECATED: use `HomeDir` instead.
+func (*Sdk) StatePath() (string, error) {
 	path := os.Getenv("SDK_STATE_DIR")
 	if path == "" {
-		panic("State directory not found in environment var SDK_STATE_DIR")
+		return "", ErrStateDirNotFound
 	}
-	return path
+	return path, nil
 }
 
 // GetConfigPath returns the path to the config directory (local to this particular op)
 // DEPRECATED: incompatible with current config API
-func (*Sdk) GetConfigPath() string {
+func (s *Sdk) GetConfigPath() string {
+	path, err := s.ConfigPath()
+	if err != nil {
+		panic(err.Error())
+	}
+	return path
+}
+
+// ConfigPath is like GetConfigPath but returns ErrConfigDirNotFound instead
+// of panicking.
+// DEPRECATED: incompatible with current config API
+func (*Sdk) ConfigPath() (string, error) {
 	path := os.Getenv("SDK_CONFIG_DIR")
 	if path == "" {
-		panic("Config directory not found in environment var SDK_CONFIG_DIR")
+		return "", ErrConfigDirNotFound
 	}
-	return path
+	return path, nil
+}
+
+// DaemonAvailable reports whether the SDK daemon can be reached, i.e.
+// whether the program is running inside an op, or whether requests are
+// served without one, in offline mode or by a handler set with
+// OptClientHandler.
+func (s *Sdk) DaemonAvailable() bool {
+	return s.DaemonAvailableContext(context.Background())
+}
+
+// DaemonAvailableContext is like DaemonAvailable but uses ctx to cancel or
+// time out the daemon request.
+func (s *Sdk) DaemonAvailableContext(ctx context.Context) bool {
+	return s.transport.Ping(ctx) == nil
 }
 
 // GetState returns a value from the state (workflow-local) key/value store
 // DEPRECATED: state is used by deprecated workflows feature
 func (s *Sdk) GetState(key string) (interface{}, error) {
-	return daemon.SyncRequest("state/get", map[string]interface{}{"key": key}, "POST")
+	return s.GetStateContext(context.Background(), key)
+}
+
+// GetStateContext is like GetState but uses ctx to cancel or time out the
+// daemon request.
+func (s *Sdk) GetStateContext(ctx context.Context, key string) (interface{}, error) {
+	return s.transport.SyncRequest(ctx, "state/get", map[string]interface{}{"key": key}, "POST")
 }
 
 // GetAllState returns a map of all keys to values in the state (workflow-local) key/value store
 // DEPRECATED: state is used by deprecated workflows feature
 func (s *Sdk) GetAllState() (map[string]interface{}, error) {
-	value, err := daemon.SyncRequest("state/get-all", map[string]interface{}{}, "POST")
+	return s.GetAllStateContext(context.Background())
+}
+
+// GetAllStateContext is like GetAllState but uses ctx to cancel or time out the
+// daemon request.
+func (s *Sdk) GetAllStateContext(ctx context.Context) (map[string]interface{}, error) {
+	value, err := s.transport.SyncRequest(ctx, "state/get-all", map[string]interface{}{}, "POST")
 	if err != nil {
 		return nil, err
 	}
@@ -81,7 +132,13 @@ func (s *Sdk) GetAllState() (map[string]interface{}, error) {
 // SetState sets a value in the state (workflow-local) key/value store
 // DEPRECATED: state is used by deprecated workflows feature
 func (s *Sdk) SetState(key string, value interface{}) error {
-	return daemon.SimpleRequest("state/set", map[string]interface{}{
+	return s.SetStateContext(context.Background(), key, value)
+}
+
+// SetStateContext is like SetState but uses ctx to cancel or time out the
+// daemon request.
+func (s *Sdk) SetStateContext(ctx context.Context, key string, value interface{}) error {
+	return s.transport.SimpleRequest(ctx, "state/set", map[string]interface{}{
 		"key":   key,
 		"value": value,
 	}, "POST")
@@ -89,7 +146,13 @@ func (s *Sdk) SetState(key string, value interface{}) error {
 
 // GetConfig returns a value from the config (user-specific) key/value store
 func (s *Sdk) GetConfig(key string) (string, error) {
-	daemonValue, err := daemon.SyncRequest("config/get", map[string]string{"key": key}, "POST")
+	return s.GetConfigContext(context.Background(), key)
+}
+
+// GetConfigContext is like GetConfig but uses ctx to cancel or time out the
+// daemon request.
+func (s *Sdk) GetConfigContext(ctx context.Context, key string) (string, error) {
+	daemonValue, err := s.transport.SyncRequest(ctx, "config/get", map[string]string{"key": key}, "POST")
 	if err != nil {
 		return "", err
 	}
@@ -107,7 +170,13 @@ func (s *Sdk) GetConfig(key string) (string, error) {
 
 // GetAllConfig returns a map of all keys to values in the config (workflow-local) key/value store
 func (s *Sdk) GetAllConfig() (map[string]string, error) {
-	value, err := daemon.SyncRequest("config/get-all", map[string]string{}, "POST")
+	return s.GetAllConfigContext(context.Background())
+}
+
+// GetAllConfigContext is like GetAllConfig but uses ctx to cancel or time out the
+// daemon request.
+func (s *Sdk) GetAllConfigContext(ctx context.Context) (map[string]string, error) {
+	value, err := s.transport.SyncRequest(ctx, "config/get-all", map[string]string{}, "POST")
 	if err != nil {
 		return nil, err
 	}
@@ -132,7 +201,13 @@ func (s *Sdk) GetAllConfig() (map[string]string, error) {
 
 // SetConfig sets a value in the config (user-specific) key/value store
 func (s *Sdk) SetConfig(key string, value string) error {
-	return daemon.SimpleRequest("config/set", map[string]string{
+	return s.SetConfigContext(context.Background(), key, value)
+}
+
+// SetConfigContext is like SetConfig but uses ctx to cancel or time out the
+// daemon request.
+func (s *Sdk) SetConfigContext(ctx context.Context, key string, value string) error {
+	return s.transport.SimpleRequest(ctx, "config/set", map[string]string{
 		"key":   key,
 		"value": value,
 	}, "POST")
@@ -141,7 +216,13 @@ func (s *Sdk) SetConfig(key string, value string) error {
 // DeleteConfig deletes a value from the config (user-specific) key/value store
 // Returns false if key not found, true if success
 func (s *Sdk) DeleteConfig(key string) (bool, error) {
-	daemonValue, err := daemon.SyncRequest("config/delete", map[string]string{"key": key}, "POST")
+	return s.DeleteConfigContext(context.Background(), key)
+}
+
+// DeleteConfigContext is like DeleteConfig but uses ctx to cancel or time out the
+// daemon request.
+func (s *Sdk) DeleteConfigContext(ctx context.Context, key string) (bool, error) {
+	daemonValue, err := s.transport.SyncRequest(ctx, "config/delete", map[string]string{"key": key}, "POST")
 	if err != nil {
 		return false, err
 	}
@@ -167,37 +248,60 @@ func OptGetSecretHidden() GetSecretOption {
 //
 // If the secret exists, it is returned, with the daemon notifying the user that it is in use.
 // Otherwise, the user is prompted to provide a replacement.
-func (*Sdk) GetSecret(key string, options ...GetSecretOption) (string, error) {
+func (s *Sdk) GetSecret(key string, options ...GetSecretOption) (string, error) {
+	return s.GetSecretContext(context.Background(), key, options...)
+}
+
+// GetSecretContext is like GetSecret but uses ctx to cancel or time out the
+// daemon request.
+func (s *Sdk) GetSecretContext(ctx context.Context, key string, options ...GetSecretOption) (string, error) {
 
 	requestBody := daemon.GetSecretBody{Key: key}
 	for _, option := range options {
 		option(&requestBody)
 	}
 
-	body, err := daemon.AsyncRequest("secret/get", requestBody, "POST")
+	body, err := s.transport.AsyncRequest(ctx, "secret/get", requestBody, "POST")
 	if err != nil {
 		return "", err
 	}
 
-	if value, ok := body[key]; ok {
-		return value.(string), nil
+	value, ok := body[key]
+	if !ok {
+		return "", fmt.Errorf("Body should include key %s", key)
+	}
+	// The value is a secret, so only its type is reported
+	secret, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("%w: secret %s is a %T, not a string", ErrUnexpectedResponse, key, value)
 	}
-	return "", fmt.Errorf("Body should include key %s", key)
+	return secret, nil
 }
 
 // SetSecret sets a particular value into the secret store
 //
 // If the secret already exists, the user is prompted on whether to overwrite it.
-func (*Sdk) SetSecret(key string, value string) (string, error) {
-	body, err := daemon.AsyncRequest("secret/set", daemon.SetSecretBody{Key: key, Value: value}, "POST")
+func (s *Sdk) SetSecret(key string, value string) (string, error) {
+	return s.SetSecretContext(context.Background(), key, value)
+}
+
+// SetSecretContext is like SetSecret but uses ctx to cancel or time out the
+// daemon request.
+func (s *Sdk) SetSecretContext(ctx context.Context, key string, value string) (string, error) {
+	body, err := s.transport.AsyncRequest(ctx, "secret/set", daemon.SetSecretBody{Key: key, Value: value}, "POST")
 	if err != nil {
 		return "", err
 	}
 
-	if value, ok := body["key"]; ok && value != nil {
-		return value.(string), nil
+	reply, ok := body["key"]
+	if !ok || reply == nil {
+		return "", fmt.Errorf("Secret set of %s failed", key)
+	}
+	setKey, ok := reply.(string)
+	if !ok {
+		return "", fmt.Errorf("%w: non-string key %v for secret %s", ErrUnexpectedResponse, reply, key)
 	}
-	return "", fmt.Errorf("Secret set of %s failed", key)
+	return setKey, nil
 }
 
 // Track sends an event to the CTO.ai analytics system.
@@ -212,7 +316,13 @@ func (*Sdk) SetSecret(key string, value string) (string, error) {
 //  })
 //
 // The event, tags, and payload will be logged.
-func (*Sdk) Track(tags []string, event string, metadata map[string]interface{}) error {
+func (s *Sdk) Track(tags []string, event string, metadata map[string]interface{}) error {
+	return s.TrackContext(context.Background(), tags, event, metadata)
+}
+
+// TrackContext is like Track but uses ctx to cancel or time out the
+// daemon request.
+func (s *Sdk) TrackContext(ctx context.Context, tags []string, event string, metadata map[string]interface{}) error {
 	requestBody := map[string]interface{}{
 		"tags":  tags,
 		"event": event,
@@ -222,13 +332,19 @@ func (*Sdk) Track(tags []string, event string, metadata map[string]interface{})
 	}
 
 	// We suppress this error to be consistent with other languages
-	_ = daemon.SimpleRequest("track", requestBody, "POST")
+	_ = s.transport.SimpleRequest(ctx, "track", requestBody, "POST")
 
 	return nil
 }
 
 // The event, tags, and payload will be logged.
-func (*Sdk) Start(workflowName string) error {
+func (s *Sdk) Start(workflowName string) error {
+	return s.StartContext(context.Background(), workflowName)
+}
+
+// StartContext is like Start but uses ctx to cancel or time out the
+// daemon request.
+func (s *Sdk) StartContext(ctx context.Context, workflowName string) error {
 	tags := [1]string{
 		"trigger",
 	}
@@ -240,13 +356,19 @@ func (*Sdk) Start(workflowName string) error {
 	}
 
 	// We suppress this error to be consistent with other languages
-	_ = daemon.SimpleRequest("track", requestBody, "POST")
+	_ = s.transport.SimpleRequest(ctx, "track", requestBody, "POST")
 
 	return nil
 }
 
-func (*Sdk) Events(start, end string) ([]map[string]interface{}, error) {
-	result, err := daemon.SyncRequest("events", daemon.EventsBody{
+func (s *Sdk) Events(start, end string) ([]map[string]interface{}, error) {
+	return s.EventsContext(context.Background(), start, end)
+}
+
+// EventsContext is like Events but uses ctx to cancel or time out the
+// daemon request.
+func (s *Sdk) EventsContext(ctx context.Context, start, end string) ([]map[string]interface{}, error) {
+	result, err := s.transport.SyncRequest(ctx, "events", daemon.EventsBody{
 		Start: start,
 		End:   end,
 	}, "POST")
@@ -280,17 +402,26 @@ type UserInfo struct {
 }
 
 // User returns the user info for the current user running the Op.
-func (*Sdk) User() (UserInfo, error) {
+func (s *Sdk) User() (UserInfo, error) {
+	return s.UserContext(context.Background())
+}
+
+// UserContext is like User but uses ctx to cancel or time out the
+// daemon request.
+func (s *Sdk) UserContext(ctx context.Context) (UserInfo, error) {
 	var body interface{}
 	method := "GET"
-	result, err := daemon.SyncRequest("user", body, method)
+	result, err := s.transport.SyncRequest(ctx, "user", body, method)
 
 	if err != nil {
 		return UserInfo{}, fmt.Errorf("error getting user information: %w", err)
 	}
 
 	// map results to UserInfo
-	mapValue := result.(map[string]interface{})
+	mapValue, ok := result.(map[string]interface{})
+	if !ok {
+		return UserInfo{}, fmt.Errorf("%w: non-object user information %v", ErrUnexpectedResponse, result)
+	}
 	userInfo := UserInfo{}
 	if id, ok := mapValue["id"].(string); ok {
 		userInfo.ID = id
@@ -319,17 +450,26 @@ type TeamInfo struct {
 }
 
 // Team returns the team info for the current user running the Op.
-func (*Sdk) Team() (TeamInfo, error) {
+func (s *Sdk) Team() (TeamInfo, error) {
+	return s.TeamContext(context.Background())
+}
+
+// TeamContext is like Team but uses ctx to cancel or time out the
+// daemon request.
+func (s *Sdk) TeamContext(ctx context.Context) (TeamInfo, error) {
 	var body interface{}
 	method := "GET"
-	result, err := daemon.SyncRequest("team", body, method)
+	result, err := s.transport.SyncRequest(ctx, "team", body, method)
 
 	if err != nil {
 		return TeamInfo{}, fmt.Errorf("error getting team information: %w", err)
 	}
 
 	// map results to TeamInfo
-	mapValue := result.(map[string]interface{})
+	mapValue, ok := result.(map[string]interface{})
+	if !ok {
+		return TeamInfo{}, fmt.Errorf("%w: non-object team information %v", ErrUnexpectedResponse, result)
+	}
 	teamInfo := TeamInfo{}
 	if id, ok := mapValue["id"].(string); ok {
 		teamInfo.ID = id
@@ -0,0 +1,72 @@
+package ctoai
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func NewSocketServer(t *testing.T, handler http.Handler) (*httptest.Server, string) {
+	dir, err := ioutil.TempDir("", "sdk-socket")
+	if err != nil {
+		t.Fatalf("Error creating socket dir: %v", err)
+	}
+
+	path := filepath.Join(dir, "daemon.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("Error listening on socket: %v", err)
+	}
+
+	ts := httptest.NewUnstartedServer(handler)
+	ts.Listener = l
+	ts.Start()
+	return ts, path
+}
+
+func Test_Socket_Option(t *testing.T) {
+	ts, path := NewSocketServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ValidateRequest(t, r, "/config/get")
+		fmt.Fprintf(w, `{"value": "config-value"}`)
+	}))
+	defer os.RemoveAll(filepath.Dir(path))
+	defer ts.Close()
+
+	c := NewClient(OptClientSocket(path))
+	output, err := c.Sdk.GetConfig("test-key")
+	if err != nil {
+		t.Errorf("Error in config request: %v", err)
+	}
+	if output != "config-value" {
+		t.Errorf("Error unexpected output: %v", output)
+	}
+}
+
+func Test_Socket_Env(t *testing.T) {
+	ts, path := NewSocketServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ValidateRequest(t, r, "/print")
+	}))
+	defer os.RemoveAll(filepath.Dir(path))
+	defer ts.Close()
+
+	err := os.Setenv("SDK_SPEAK_SOCKET", path)
+	if err != nil {
+		t.Errorf("Error setting test env variable: %s", err)
+	}
+	defer os.Unsetenv("SDK_SPEAK_SOCKET")
+
+	err = os.Setenv("SDK_SPEAK_PORT", "1")
+	if err != nil {
+		t.Errorf("Error setting test env variable: %s", err)
+	}
+
+	u := NewUx()
+	if err := u.Print("test"); err != nil {
+		t.Errorf("Error printing test value: %v", err)
+	}
+}
@@ -1,13 +1,20 @@
 package ctoai
 
 import (
+	"encoding/json"
+	"fmt"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"testing"
+
+	"github.com/cto-ai/sdk-go/v2/internal/daemon"
 )
 
+// fullCapabilities is the capabilities of a daemon supporting everything
+var fullCapabilities = daemon.FullCapabilities(daemon.ProtocolVersion)
+
 func ValidateRequest(t *testing.T, r *http.Request, path string) {
 	if r.Header["Content-Type"][0] != "application/json" {
 		t.Errorf("Headers incorrect: %v", r.Header["Content-Type"])
@@ -33,3 +40,24 @@ func SetPortVar(t *testing.T, ts *httptest.Server) {
 		t.Errorf("Error setting test env variable: %s", err)
 	}
 }
+
+// HandleCapabilities answers a capabilities request like a daemon that
+// predates capability negotiation, and reports whether r was one
+func HandleCapabilities(w http.ResponseWriter, r *http.Request) bool {
+	if r.URL.Path != "/capabilities" {
+		return false
+	}
+	w.WriteHeader(http.StatusNotFound)
+	return true
+}
+
+// HandleFullCapabilities answers a capabilities request like a daemon that
+// supports everything, and reports whether r was one
+func HandleFullCapabilities(w http.ResponseWriter, r *http.Request) bool {
+	if r.URL.Path != "/capabilities" {
+		return false
+	}
+	value, _ := json.Marshal(fullCapabilities)
+	fmt.Fprintf(w, `{"value": %s}`, value)
+	return true
+}
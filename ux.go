@@ -1,18 +1,33 @@
 package ctoai
 
 import (
+	"context"
 	"fmt"
 	"os"
 
 	"github.com/cto-ai/sdk-go/v2/internal/daemon"
 )
 
-// Ux is the object that contains the UX methods
-type Ux struct{}
+// Ux is the object that contains the UX methods.
+//
+// Its methods are safe for concurrent use: UI operations are sent to the
+// daemon one at a time, in the order they were made.
+type Ux struct {
+	transport *daemon.Client
+	ui        dispatcher
+}
+
+// NewUx creates a new Ux object and returns it. It takes the same options
+// as NewClient.
+func NewUx(options ...ClientOption) *Ux {
+	o := newClientOptions(options)
+	return newUx(newTransport(o), o)
+}
 
-// NewUx creates a new Ux object and returns it
-func NewUx() *Ux {
-	return &Ux{}
+func newUx(transport *daemon.Client, o *clientOptions) *Ux {
+	u := &Ux{transport: transport}
+	u.ui.queuePrints = o.queuePrints
+	return u
 }
 
 // Bold adds formatting for boldface type to the given text
@@ -44,14 +59,26 @@ func (*Ux) Italic(text string) string {
 // Output:
 //
 // testing
-func (*Ux) Print(text string) error {
-	return daemon.SimpleRequest("print", daemon.PrintBody{Text: text}, "POST")
+func (u *Ux) Print(text string) error {
+	return u.PrintContext(context.Background(), text)
+}
+
+// PrintContext is like Print but uses ctx to cancel or time out the
+// daemon request.
+func (u *Ux) PrintContext(ctx context.Context, text string) error {
+	return u.ui.dispatch(ctx, func() error {
+		return u.show(ctx, text)
+	})
 }
 
 // SpinnerStart presents a spinner on the output interface
 // (i.e. terminal or slack) that to spin until the SpinnerStop method
 // is called.
 //
+// A spinner started with SpinnerStart replaces the previous one started
+// with SpinnerStart. Use StartSpinner when several goroutines show
+// spinners.
+//
 // Example:
 //
 //  u := ctoai.NewUx()
@@ -62,13 +89,25 @@ func (*Ux) Print(text string) error {
 //
 // Output:
 // [spinner emoji w/ spinner animation here] Starting process...
-func (*Ux) SpinnerStart(text string) error {
-	return daemon.SimpleRequest("start-spinner", daemon.SpinnerStartBody{Text: text}, "POST")
+func (u *Ux) SpinnerStart(text string) error {
+	return u.SpinnerStartContext(context.Background(), text)
+}
+
+// SpinnerStartContext is like SpinnerStart but uses ctx to cancel or time out the
+// daemon request.
+func (u *Ux) SpinnerStartContext(ctx context.Context, text string) error {
+	return u.ui.dispatch(ctx, func() error {
+		_, err := u.pushSpinner(ctx, text, true)
+		return err
+	})
 }
 
 // SpinnerStop stops a spinner that has been previously started on the
 // interface (i.e. terminal or slack).
 //
+// It stops the spinner currently shown, whoever started it; Spinner.Stop
+// stops a particular one.
+//
 // Example:
 //
 //  ... //previous spinner started here
@@ -80,14 +119,29 @@ func (*Ux) SpinnerStart(text string) error {
 //
 // Output:
 // [spinner completed completed here] Done!
-func (*Ux) SpinnerStop(text string) error {
-	return daemon.SimpleRequest("stop-spinner", daemon.SpinnerStopBody{Text: text}, "POST")
+func (u *Ux) SpinnerStop(text string) error {
+	return u.SpinnerStopContext(context.Background(), text)
+}
+
+// SpinnerStopContext is like SpinnerStop but uses ctx to cancel or time out the
+// daemon request.
+func (u *Ux) SpinnerStopContext(ctx context.Context, text string) error {
+	return u.ui.dispatch(ctx, func() error {
+		if len(u.ui.spinners) == 0 {
+			return u.transport.SimpleRequest(ctx, "stop-spinner", daemon.SpinnerStopBody{Text: text}, "POST")
+		}
+		return u.stopSpinner(ctx, u.ui.spinners[len(u.ui.spinners)-1], text)
+	})
 }
 
 // ProgressBarStart presents a progressbar on the output interface
 // (i.e. terminal or slack) that will stay present until the
 // progressbar stop method is called.
 //
+// A progress bar started with ProgressBarStart replaces the previous one
+// started with ProgressBarStart. Use StartProgressBar when several
+// goroutines show progress bars.
+//
 // The input length is the total length of the progress bar, e.g.
 // if you have 5 steps in your logic, then a unit length of 5 might be
 // and appropriate length.
@@ -105,8 +159,17 @@ func (*Ux) SpinnerStop(text string) error {
 //
 // Output:
 // [progressbar animation with 1/5 of the bar filled here] Downloading...
-func (*Ux) ProgressBarStart(length, initial int, message string) error {
-	return daemon.SimpleRequest("progress-bar/start", daemon.ProgressBarStartBody{Length: length, Initial: initial, Text: message}, "POST")
+func (u *Ux) ProgressBarStart(length, initial int, message string) error {
+	return u.ProgressBarStartContext(context.Background(), length, initial, message)
+}
+
+// ProgressBarStartContext is like ProgressBarStart but uses ctx to cancel or time out the
+// daemon request.
+func (u *Ux) ProgressBarStartContext(ctx context.Context, length, initial int, message string) error {
+	return u.ui.dispatch(ctx, func() error {
+		_, err := u.pushBar(ctx, length, initial, message, true)
+		return err
+	})
 }
 
 // ProgressBarAdvance adds onto a progressbar that is already present
@@ -126,8 +189,19 @@ func (*Ux) ProgressBarStart(length, initial int, message string) error {
 //
 // Output:
 // [progressbar animation with 2/5 of the bar filled here] Downloading...
-func (*Ux) ProgressBarAdvance(increment int) error {
-	return daemon.SimpleRequest("progress-bar/advance", daemon.ProgressBarAdvanceBody{Increment: increment}, "POST")
+func (u *Ux) ProgressBarAdvance(increment int) error {
+	return u.ProgressBarAdvanceContext(context.Background(), increment)
+}
+
+// ProgressBarAdvanceContext is like ProgressBarAdvance but uses ctx to cancel or time out the
+// daemon request.
+func (u *Ux) ProgressBarAdvanceContext(ctx context.Context, increment int) error {
+	return u.ui.dispatch(ctx, func() error {
+		if len(u.ui.bars) == 0 {
+			return u.transport.SimpleRequest(ctx, "progress-bar/advance", daemon.ProgressBarAdvanceBody{Increment: increment}, "POST")
+		}
+		return u.advanceBar(ctx, u.ui.bars[len(u.ui.bars)-1], increment)
+	})
 }
 
 // ProgressBarStop completes a progressbar that is already present on
@@ -146,6 +220,121 @@ func (*Ux) ProgressBarAdvance(increment int) error {
 //
 // Output:
 // [progressbar animation with 5/5 of the bar filled here] Done!
-func (*Ux) ProgressBarStop(message string) error {
-	return daemon.SimpleRequest("progress-bar/stop", daemon.ProgressBarStopBody{Text: message}, "POST")
+func (u *Ux) ProgressBarStop(message string) error {
+	return u.ProgressBarStopContext(context.Background(), message)
+}
+
+// ProgressBarStopContext is like ProgressBarStop but uses ctx to cancel or time out the
+// daemon request.
+func (u *Ux) ProgressBarStopContext(ctx context.Context, message string) error {
+	return u.ui.dispatch(ctx, func() error {
+		if len(u.ui.bars) == 0 {
+			return u.transport.SimpleRequest(ctx, "progress-bar/stop", daemon.ProgressBarStopBody{Text: message}, "POST")
+		}
+		return u.stopBar(ctx, u.ui.bars[len(u.ui.bars)-1], message)
+	})
+}
+
+// spinner implements Spinner for Ux
+type spinner struct {
+	ux     *Ux
+	text   string
+	legacy bool
+}
+
+// StartSpinner presents a spinner on the output interface (i.e. terminal
+// or slack) until the returned Spinner is stopped.
+//
+// Unlike SpinnerStart, StartSpinner is safe to use from several goroutines
+// at once: each goroutine stops its own spinner.
+//
+// Example:
+//
+//  u := ctoai.NewUx()
+//  spinner, err := u.StartSpinner("Deploying api...")
+//  if err != nil {
+//      panic(err)
+//  }
+//  defer spinner.Stop("Deployed api")
+func (u *Ux) StartSpinner(text string) (Spinner, error) {
+	return u.StartSpinnerContext(context.Background(), text)
+}
+
+// StartSpinnerContext is like StartSpinner but uses ctx to cancel or time
+// out the daemon request.
+func (u *Ux) StartSpinnerContext(ctx context.Context, text string) (Spinner, error) {
+	var s *spinner
+	err := u.ui.dispatch(ctx, func() error {
+		var err error
+		s, err = u.pushSpinner(ctx, text, false)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *spinner) Stop(text string) error {
+	return s.StopContext(context.Background(), text)
+}
+
+func (s *spinner) StopContext(ctx context.Context, text string) error {
+	return s.ux.ui.dispatch(ctx, func() error {
+		return s.ux.stopSpinner(ctx, s, text)
+	})
+}
+
+// progressBar implements ProgressBar for Ux
+type progressBar struct {
+	ux      *Ux
+	length  int
+	current int
+	text    string
+	legacy  bool
+}
+
+// StartProgressBar presents a progress bar on the output interface (i.e.
+// terminal or slack) until the returned ProgressBar is stopped; see
+// ProgressBarStart.
+//
+// Unlike ProgressBarStart, StartProgressBar is safe to use from several
+// goroutines at once: each goroutine advances and stops its own bar.
+func (u *Ux) StartProgressBar(length, initial int, message string) (ProgressBar, error) {
+	return u.StartProgressBarContext(context.Background(), length, initial, message)
+}
+
+// StartProgressBarContext is like StartProgressBar but uses ctx to cancel
+// or time out the daemon request.
+func (u *Ux) StartProgressBarContext(ctx context.Context, length, initial int, message string) (ProgressBar, error) {
+	var b *progressBar
+	err := u.ui.dispatch(ctx, func() error {
+		var err error
+		b, err = u.pushBar(ctx, length, initial, message, false)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (b *progressBar) Advance(increment int) error {
+	return b.AdvanceContext(context.Background(), increment)
+}
+
+func (b *progressBar) AdvanceContext(ctx context.Context, increment int) error {
+	return b.ux.ui.dispatch(ctx, func() error {
+		return b.ux.advanceBar(ctx, b, increment)
+	})
+}
+
+func (b *progressBar) Stop(message string) error {
+	return b.StopContext(context.Background(), message)
+}
+
+func (b *progressBar) StopContext(ctx context.Context, message string) error {
+	return b.ux.ui.dispatch(ctx, func() error {
+		return b.ux.stopBar(ctx, b, message)
+	})
 }
@@ -0,0 +1,202 @@
+package ctoai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/cto-ai/sdk-go/v2/internal/daemon"
+)
+
+// defaultAttempts is how many times a prompt with a validation option is
+// asked, unless set with OptClientValidationAttempts
+const defaultAttempts = 3
+
+// OptClientValidationAttempts sets how many times a prompt with a
+// validation option, such as OptInputValidate, is asked before the
+// validation error is returned. Defaults to 3.
+func OptClientValidationAttempts(attempts int) ClientOption {
+	return func(o *clientOptions) {
+		o.attempts = attempts
+	}
+}
+
+// answerDecoder extracts the typed answer to the prompt called name from
+// the daemon's reply
+type answerDecoder func(body map[string]interface{}, name string) (interface{}, error)
+
+// checker is implemented by every prompt definition
+type checker interface {
+	Check(answer interface{}) error
+}
+
+// askValidated asks a prompt and decodes its answer, asking again until
+// the answer passes the prompt's validation
+func (p *Prompt) askValidated(ctx context.Context, definition interface{}, name string, decode answerDecoder) (interface{}, error) {
+	body, err := p.ask(ctx, definition)
+	if err != nil {
+		return nil, err
+	}
+	answer, err := decode(body, name)
+	if err != nil {
+		return nil, err
+	}
+	return p.validated(ctx, definition, name, decode, answer)
+}
+
+// validated checks answer, the first answer to definition. While it fails
+// validation, the validation message is printed and the prompt is asked
+// again with the rejected answer as its default, up to the configured
+// number of attempts.
+func (p *Prompt) validated(ctx context.Context, definition interface{}, name string, decode answerDecoder, answer interface{}) (interface{}, error) {
+	check, ok := definition.(checker)
+	if !ok {
+		return answer, nil
+	}
+
+	attempts := p.attempts
+	if attempts <= 0 {
+		attempts = defaultAttempts
+	}
+	for attempt := 1; ; attempt++ {
+		invalid := check.Check(answer)
+		if invalid == nil {
+			return answer, nil
+		}
+		if attempt >= attempts {
+			return nil, fmt.Errorf("Error in answer to %s: %w", name, invalid)
+		}
+		if err := p.printer().PrintContext(ctx, invalid.Error()); err != nil {
+			return nil, err
+		}
+
+		check = withDefault(check, answer)
+		body, err := p.ask(ctx, check)
+		if err != nil {
+			return nil, err
+		}
+		answer, err = decode(body, name)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// withDefault returns a copy of definition that defaults to answer.
+// Secret and password prompts are asked again without a default.
+func withDefault(definition checker, answer interface{}) checker {
+	switch d := definition.(type) {
+	case daemon.InputPromptBody:
+		d.Default, _ = answer.(string)
+		return d
+	case daemon.NumberPromptBody:
+		d.DefaultValue, _ = answer.(int)
+		d.DefaultSet = true
+		return d
+	case daemon.NumericPromptBody:
+		switch v := answer.(type) {
+		case float64:
+			d.Default = formatFloat(v)
+		case int64:
+			d.Default = json.Number(strconv.FormatInt(v, 10))
+		case uint64:
+			d.Default = json.Number(strconv.FormatUint(v, 10))
+		}
+		return d
+	case daemon.ConfirmPromptBody:
+		d.Default, _ = answer.(bool)
+		return d
+	case daemon.ListPromptBody:
+		d.DefaultValue, _ = answer.(string)
+		if selected, ok := answer.(selection); ok {
+			d.DefaultValue = selected.keys[0]
+		}
+		d.DefaultSet = true
+		d.DefaultIsValue = true
+		return d
+	case daemon.CheckboxPromptBody:
+		d.DefaultValue, _ = answer.([]string)
+		if selected, ok := answer.(selection); ok {
+			d.DefaultValue = selected.keys
+		}
+		d.DefaultSet = true
+		d.DefaultIsValue = true
+		return d
+	case daemon.EditorPromptBody:
+		d.Default, _ = answer.(string)
+		return d
+	case daemon.DatetimePromptBody:
+		if t, ok := answer.(time.Time); ok {
+			d.Default = t.Format(time.RFC3339)
+		}
+		return d
+	case daemon.DurationPromptBody:
+		switch v := answer.(type) {
+		case time.Duration:
+			d.Default = v.String()
+		case string:
+			d.Default = v
+		}
+		return d
+	}
+	return definition
+}
+
+// The following decoders return the typed answers as interface{} values,
+// for askValidated and Form.
+
+func decodeStringAnswer(body map[string]interface{}, name string) (interface{}, error) {
+	return decodeString(body, name)
+}
+
+func decodeNumberAnswer(body map[string]interface{}, name string) (interface{}, error) {
+	return decodeNumber(body, name)
+}
+
+func decodeInt64Answer(body map[string]interface{}, name string) (interface{}, error) {
+	return decodeInt64(body, name)
+}
+
+func decodeUint64Answer(body map[string]interface{}, name string) (interface{}, error) {
+	return decodeUint64(body, name)
+}
+
+func decodeFloatAnswer(body map[string]interface{}, name string) (interface{}, error) {
+	return decodeFloat(body, name)
+}
+
+func decodeBoolAnswer(body map[string]interface{}, name string) (interface{}, error) {
+	return decodeBool(body, name)
+}
+
+func decodeStringsAnswer(body map[string]interface{}, name string) (interface{}, error) {
+	return decodeStrings(body, name)
+}
+
+func decodeDatetimeAnswer(body map[string]interface{}, name string) (interface{}, error) {
+	return decodeDatetime(body, name)
+}
+
+// decodeDurationAnswer returns the text typed by the user in place of a
+// duration if it cannot be parsed, so that the prompt's Check rejects it
+func decodeDurationAnswer(body map[string]interface{}, name string) (interface{}, error) {
+	text, err := decodeString(body, name)
+	if err != nil {
+		return nil, err
+	}
+	if d, err := daemon.ParseDuration(text); err == nil {
+		return d, nil
+	}
+	return text, nil
+}
+
+// printer returns the UX that rejected answers are reported on, which for
+// a zero-value Prompt is a Ux sharing its transport
+func (p *Prompt) printer() UX {
+	if p.ux == nil {
+		return &Ux{transport: p.transport}
+	}
+	return p.ux
+}
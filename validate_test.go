@@ -0,0 +1,222 @@
+package ctoai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// scriptedPrompts answers prompts in order from answers and records the
+// endpoints requested, the definitions asked and the text printed. It reports capabilities, if set,
+// and otherwise answers like a daemon that predates capability negotiation.
+type scriptedPrompts struct {
+	capabilities interface{}
+	answers      []interface{}
+	endpoints    []string
+	definitions  []map[string]interface{}
+	prints       []string
+}
+
+func (s *scriptedPrompts) handle(ctx context.Context, req *DaemonRequest) (interface{}, error) {
+	bytes, _ := json.Marshal(req.Body)
+	var body map[string]interface{}
+	json.Unmarshal(bytes, &body)
+	s.endpoints = append(s.endpoints, req.Endpoint)
+
+	switch req.Endpoint {
+	case "capabilities":
+		if s.capabilities == nil {
+			return nil, &DaemonError{StatusCode: http.StatusNotFound, Endpoint: req.Endpoint, Method: req.Method}
+		}
+		return s.capabilities, nil
+	case "print":
+		s.prints = append(s.prints, body["text"].(string))
+		return nil, nil
+	case "prompts":
+		answers := make(map[string]interface{})
+		for _, p := range body["prompts"].([]interface{}) {
+			definition := p.(map[string]interface{})
+			s.definitions = append(s.definitions, definition)
+			answers[definition["name"].(string)] = s.next()
+		}
+		return answers, nil
+	}
+	s.definitions = append(s.definitions, body)
+	return map[string]interface{}{body["name"].(string): s.next()}, nil
+}
+
+func (s *scriptedPrompts) next() interface{} {
+	answer := s.answers[0]
+	s.answers = s.answers[1:]
+	return answer
+}
+
+var errNotVersion = errors.New("not a version")
+
+func validateVersion(answer string) error {
+	if !strings.HasPrefix(answer, "v") {
+		return fmt.Errorf("%q is %w", answer, errNotVersion)
+	}
+	return nil
+}
+
+func Test_Validate_Reprompt(t *testing.T) {
+	script := &scriptedPrompts{answers: []interface{}{"1.0", "1.1", "v1.1"}}
+	p := NewPrompt(OptClientHandler(script.handle))
+
+	tag, err := p.Input("tag", "Version?", OptInputDefault("v0"), OptInputValidate(validateVersion))
+	if err != nil {
+		t.Fatalf("Error in prompt request: %v", err)
+	}
+	if tag != "v1.1" {
+		t.Errorf("Error unexpected answer: %v", tag)
+	}
+
+	expectedPrints := []string{`"1.0" is not a version`, `"1.1" is not a version`}
+	if !reflect.DeepEqual(script.prints, expectedPrints) {
+		t.Errorf("Error unexpected prints: %v", script.prints)
+	}
+
+	var defaults []interface{}
+	for _, definition := range script.definitions {
+		defaults = append(defaults, definition["default"])
+	}
+	if expected := []interface{}{"v0", "1.0", "1.1"}; !reflect.DeepEqual(defaults, expected) {
+		t.Errorf("Error unexpected defaults: %v", defaults)
+	}
+}
+
+func Test_Validate_ZeroValuePrompt(t *testing.T) {
+	answers := []string{"1.0", "v1.0"}
+	var prints []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/print":
+			var body struct{ Text string }
+			json.NewDecoder(r.Body).Decode(&body)
+			prints = append(prints, body.Text)
+		case "/prompt":
+			reply := fmt.Sprintf(`{"tag": %q}`, answers[0])
+			answers = answers[1:]
+			if err := ioutil.WriteFile("/tmp/response-mocktest", []byte(reply), 0600); err != nil {
+				t.Fatal(err)
+			}
+			fmt.Fprint(w, `{"replyFilename": "/tmp/response-mocktest"}`)
+		default:
+			t.Errorf("Error unexpected request: %s", r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+
+	SetPortVar(t, ts)
+
+	var p Prompt
+	tag, err := p.Input("tag", "Version?", OptInputValidate(validateVersion))
+	if err != nil {
+		t.Fatalf("Error in prompt request: %v", err)
+	}
+	if tag != "v1.0" {
+		t.Errorf("Error unexpected answer: %v", tag)
+	}
+	if expected := []string{`"1.0" is not a version`}; !reflect.DeepEqual(prints, expected) {
+		t.Errorf("Error unexpected prints: %v", prints)
+	}
+}
+
+func Test_Validate_Attempts(t *testing.T) {
+	script := &scriptedPrompts{answers: []interface{}{float64(1), float64(2)}}
+	p := NewPrompt(OptClientHandler(script.handle), OptClientValidationAttempts(2))
+
+	_, err := p.Number("replicas", "How many?", OptNumberValidate(func(n int) error {
+		if n%2 != 0 {
+			return errNotVersion
+		}
+		return nil
+	}))
+	if err != nil {
+		t.Fatalf("Error in prompt request: %v", err)
+	}
+
+	script.answers = []interface{}{float64(1), float64(3)}
+	_, err = p.Number("replicas", "How many?", OptNumberValidate(func(n int) error {
+		if n%2 != 0 {
+			return errNotVersion
+		}
+		return nil
+	}))
+	if !errors.Is(err, errNotVersion) {
+		t.Errorf("Error expected the validation error after the last attempt, got: %v", err)
+	}
+	if len(script.answers) != 0 {
+		t.Errorf("Error expected two attempts, %d answers left", len(script.answers))
+	}
+}
+
+func Test_Validate_Form(t *testing.T) {
+	script := &scriptedPrompts{answers: []interface{}{"1.0", true, "v1.0"}}
+	c := NewClient(OptClientHandler(script.handle))
+
+	answers, err := c.Prompt.Form().
+		Input("tag", "Version?", OptInputValidate(validateVersion)).
+		Confirm("sure", "Sure?").
+		Run()
+	if err != nil {
+		t.Fatalf("Error in form request: %v", err)
+	}
+	if answers.String("tag") != "v1.0" || !answers.Bool("sure") {
+		t.Errorf("Error unexpected answers: %v %v", answers.String("tag"), answers.Bool("sure"))
+	}
+
+	if len(script.definitions) != 3 || script.definitions[2]["name"] != "tag" || script.definitions[2]["default"] != "1.0" {
+		t.Errorf("Error expected the tag prompt to be asked again: %v", script.definitions)
+	}
+	if len(script.prints) != 1 {
+		t.Errorf("Error unexpected prints: %v", script.prints)
+	}
+}
+
+func Test_Validate_InputConstraints(t *testing.T) {
+	script := &scriptedPrompts{answers: []interface{}{"x", "Bad Name", "good-name"}}
+	p := NewPrompt(OptClientHandler(script.handle))
+
+	name, err := p.Input("name", "DNS name?",
+		OptInputPattern(`[a-z0-9-]+`),
+		OptInputMinLength(2),
+		OptInputMaxLength(63),
+		OptInputErrorMessage("Use 2 to 63 lowercase letters, digits and dashes"),
+	)
+	if err != nil {
+		t.Fatalf("Error in prompt request: %v", err)
+	}
+	if name != "good-name" {
+		t.Errorf("Error unexpected answer: %v", name)
+	}
+
+	definition := script.definitions[0]
+	if definition["pattern"] != "[a-z0-9-]+" || definition["minLength"] != float64(2) || definition["maxLength"] != float64(63) || definition["errorMessage"] != "Use 2 to 63 lowercase letters, digits and dashes" {
+		t.Errorf("Error constraints not sent to the daemon: %v", definition)
+	}
+	if len(script.prints) != 2 || script.prints[0] != "Use 2 to 63 lowercase letters, digits and dashes" {
+		t.Errorf("Error unexpected prints: %v", script.prints)
+	}
+
+	script.answers = []interface{}{""}
+	if _, err := p.Input("name", "DNS name?", OptInputPattern(`[a-z]+`), OptInputAllowEmpty(true)); err != nil {
+		t.Errorf("Error expected an allowed empty answer to skip the constraints: %v", err)
+	}
+
+	asked := len(script.definitions)
+	if _, err := p.Input("name", "DNS name?", OptInputPattern(`[a-z`)); err == nil {
+		t.Errorf("Error expected an invalid pattern to be rejected")
+	}
+	if len(script.definitions) != asked {
+		t.Errorf("Error expected no prompt with an invalid pattern")
+	}
+}